@@ -0,0 +1,88 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"register-payment/internal/config"
+	"register-payment/internal/handler"
+	"register-payment/internal/repository"
+	"register-payment/internal/service"
+	"register-payment/pkg/database"
+	"register-payment/pkg/money"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	log.Println("Starting Transaction Read API...")
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	cfg := config.Load()
+
+	// Connect to database
+	dbConfig := database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.DBName,
+		SSLMode:  cfg.Database.SSLMode,
+	}
+
+	db, err := database.NewPostgresDB(dbConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	transactionRepo := repository.NewTransactionRepository(db.DB)
+	balanceRepo := repository.NewBalanceRepository(db.DB)
+	ledgerRepo := repository.NewLedgerRepository(db.DB)
+
+	// Updates post new journal entries, so the API applies the same rules as the consumer
+	feeRounding, err := money.ParseRoundingMode(cfg.Ledger.FeeRounding)
+	if err != nil {
+		log.Fatalf("Invalid ledger fee rounding: %v", err)
+	}
+	postingRules := service.PostingRules{
+		FeeBasisPoints: int64(cfg.Ledger.FeeBasisPoints),
+		FeeRounding:    feeRounding,
+	}
+
+	timeouts := service.Timeouts{
+		Read:   cfg.Database.ReadTimeout,
+		Write:  cfg.Database.WriteTimeout,
+		Export: cfg.Database.ExportTimeout,
+	}
+
+	transactionService := service.NewTransactionService(transactionRepo, balanceRepo, ledgerRepo, postingRules, timeouts)
+	transactionHandler := handler.NewTransactionHandler(transactionService)
+	reportService := service.NewReportService(repository.NewReportRepository(db.DB), timeouts)
+	reportHandler := handler.NewReportHandler(reportService)
+	exportHandler := handler.NewExportHandler(service.NewExportService(transactionRepo, timeouts))
+
+	router := gin.New()
+	router.Use(gin.Logger())
+	router.Use(gin.Recovery())
+
+	api := router.Group("/api/v1")
+	transactionHandler.RegisterRoutes(api)
+	reportHandler.RegisterRoutes(api)
+	exportHandler.RegisterRoutes(api)
+	api.GET("/health", func(c *gin.Context) {
+		if err := db.PingContext(c.Request.Context()); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "service": "transaction-api"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "transaction-api"})
+	})
+
+	log.Printf("Transaction Read API starting on 0.0.0.0:%s", cfg.Server.Port)
+	if err := router.Run("0.0.0.0:" + cfg.Server.Port); err != nil {
+		log.Fatalf("Failed to start read API: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"register-payment/internal/config"
@@ -10,9 +11,12 @@ import (
 	"register-payment/internal/repository"
 	"register-payment/internal/service"
 	"register-payment/pkg/database"
+	"register-payment/pkg/money"
 	"register-payment/pkg/rabbitmq"
 	"syscall"
 	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 func main() {
@@ -57,11 +61,42 @@ func main() {
 
 	// Initialize services (Consumer only needs write operations)
 	transactionRepo := repository.NewTransactionRepository(db.DB)
-	transactionService := service.NewTransactionService(transactionRepo)
-	consumerHandler := handler.NewConsumerHandler(transactionService)
+	balanceRepo := repository.NewBalanceRepository(db.DB)
+	ledgerRepo := repository.NewLedgerRepository(db.DB)
+
+	feeRounding, err := money.ParseRoundingMode(cfg.Ledger.FeeRounding)
+	if err != nil {
+		log.Fatalf("Invalid ledger fee rounding: %v", err)
+	}
+	postingRules := service.PostingRules{
+		FeeBasisPoints: int64(cfg.Ledger.FeeBasisPoints),
+		FeeRounding:    feeRounding,
+	}
+
+	timeouts := service.Timeouts{
+		Read:  cfg.Database.ReadTimeout,
+		Write: cfg.Database.WriteTimeout,
+	}
+
+	transactionService := service.NewTransactionService(transactionRepo, balanceRepo, ledgerRepo, postingRules, timeouts)
+	statusService := service.NewStatusService(repository.NewStatusRepository(db.DB), timeouts)
+	consumerHandler := handler.NewConsumerHandler(transactionService, statusService)
 
 	// Start RabbitMQ consumer
-	consumer := rabbitmq.NewConsumer(rabbitConn, cfg.RabbitMQ.Queue, consumerHandler.ProcessTransaction)
+	consumerOptions := rabbitmq.ConsumerOptions{
+		Retry: rabbitmq.RetryPolicy{
+			MaxRetries: cfg.RabbitMQ.MaxRetries,
+			BaseDelay:  cfg.RabbitMQ.RetryDelay,
+			MaxDelay:   cfg.RabbitMQ.MaxRetryDelay,
+		},
+		OnRetriesExhausted: consumerHandler.RetriesExhausted,
+		Workers:            cfg.RabbitMQ.Workers,
+		Prefetch:           cfg.RabbitMQ.Prefetch,
+	}
+	if cfg.RabbitMQ.OrderByCompany {
+		consumerOptions.OrderingKey = handler.CompanyOrderingKey
+	}
+	consumer := rabbitmq.NewConsumer(rabbitConn, cfg.RabbitMQ.Queue, consumerHandler.ProcessTransaction, consumerOptions)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -69,10 +104,13 @@ func main() {
 		log.Fatalf("Failed to start consumer: %v", err)
 	}
 
-	log.Printf("Consumer started, processing transactions from queue: %s", cfg.RabbitMQ.Queue)
+	stats := consumer.Stats()
+	log.Printf("Consumer started, processing transactions from queue: %s (workers: %d, prefetch: %d)",
+		cfg.RabbitMQ.Queue, stats.Workers, stats.Prefetch)
+	go logConsumerStats(ctx, consumer)
 
-	// Optional: Start health check server
-	go startHealthServer(consumerHandler, cfg.Server.Port)
+	// Health and metrics server, including the consumer's in-flight work
+	go startHealthServer(consumerHandler, consumer, rabbitConn, cfg.Server.Port)
 
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
@@ -81,17 +119,78 @@ func main() {
 
 	log.Println("Shutting down consumer...")
 	cancel() // Stop RabbitMQ consumer
-	
-	// Allow graceful shutdown
-	time.Sleep(2 * time.Second)
-	log.Println("Consumer stopped")
+
+	// Let the workers finish the messages in progress before the connections close;
+	// messages not started yet are requeued
+	stopped := make(chan struct{})
+	go func() {
+		consumer.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		log.Println("Consumer stopped")
+	case <-time.After(shutdownTimeout):
+		log.Printf("Consumer did not stop within %v, unacknowledged messages will be redelivered", shutdownTimeout)
+	}
 }
 
-func startHealthServer(handler *handler.ConsumerHandler, port string) {
+// shutdownTimeout bounds how long shutdown waits for the messages in progress
+const shutdownTimeout = 30 * time.Second
+
+// logConsumerStats logs the consumer's in-flight work every minute while it has any
+func logConsumerStats(ctx context.Context, consumer *rabbitmq.Consumer) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	var handled int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := consumer.Stats()
+			if stats.InFlight == 0 && stats.Handled == handled {
+				continue
+			}
+			log.Printf("Consumer stats: %d in flight, %d active, %d handled in the last minute",
+				stats.InFlight, stats.Active, stats.Handled-handled)
+			handled = stats.Handled
+		}
+	}
+}
+
+func startHealthServer(handler *handler.ConsumerHandler, consumer *rabbitmq.Consumer, conn *rabbitmq.Connection, port string) {
 	// Simple health check server for the consumer
 	// This allows monitoring tools to check if the consumer is alive
 	log.Printf("Consumer health server starting on port %s", port)
-	
-	// This would start a minimal HTTP server for health checks
-	// Implementation depends on your monitoring requirements
-}
\ No newline at end of file
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	router.GET("/health", func(c *gin.Context) {
+		status := "ok"
+		if !handler.IsHealthy() {
+			status = "idle"
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":    status,
+			"rabbitmq":  conn.State().String(),
+			"service":   "transaction-consumer",
+			"timestamp": time.Now().UTC(),
+		})
+	})
+
+	router.GET("/metrics", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"metrics":   handler.GetMetrics(),
+			"consumer":  consumer.Stats(),
+			"uptime":    handler.GetUptime().String(),
+			"timestamp": time.Now().UTC(),
+		})
+	})
+
+	if err := router.Run(":" + port); err != nil {
+		log.Printf("Consumer health server stopped: %v", err)
+	}
+}
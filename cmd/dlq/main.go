@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"register-payment/internal/config"
+	"register-payment/pkg/rabbitmq"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Inspects and reprocesses dead-lettered transaction messages, e.g.
+//
+//	go run ./cmd/dlq list
+//	go run ./cmd/dlq replay 3f2a9c0e... 9bc14d7a...
+//	go run ./cmd/dlq -all replay
+//	go run ./cmd/dlq edit 3f2a9c0e...
+//	go run ./cmd/dlq purge 3f2a9c0e...
+func main() {
+	queue := flag.String("queue", "", "queue whose dead letters to use (default RABBITMQ_QUEUE)")
+	limit := flag.Int("limit", 100, "most dead letters to list, 0 for all")
+	asJSON := flag.Bool("json", false, "list dead letters as NDJSON, with headers and body")
+	all := flag.Bool("all", false, "replay or purge every dead letter instead of the given message ids")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] list | replay ID... | edit ID | purge ID...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	command, ids := flag.Arg(0), flag.Args()
+	if len(ids) > 0 {
+		ids = ids[1:]
+	}
+	switch {
+	case command == "list" && len(ids) == 0 && !*all:
+	case (command == "replay" || command == "purge") && (len(ids) > 0) != *all:
+	case command == "edit" && len(ids) == 1 && !*all:
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+	cfg := config.Load()
+	if *queue == "" {
+		*queue = cfg.RabbitMQ.Queue
+	}
+
+	conn, err := rabbitmq.NewConnection(rabbitmq.Config{
+		URL:        cfg.RabbitMQ.URL,
+		MaxRetries: 3,
+		RetryDelay: 2 * time.Second,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+	}
+	defer conn.Close()
+
+	deadLetters := rabbitmq.NewDeadLetters(conn, *queue)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch command {
+	case "list":
+		err = list(deadLetters, *limit, *asJSON)
+	case "replay":
+		err = remove(deadLetters, ids, "Replayed", func(letter *rabbitmq.DeadLetter) error {
+			return deadLetters.Replay(ctx, letter, nil)
+		})
+	case "edit":
+		err = edit(ctx, deadLetters, ids[0])
+	case "purge":
+		if *all {
+			var purged int
+			if purged, err = deadLetters.Purge(); err == nil {
+				log.Printf("Purged %d dead letters from %s", purged, rabbitmq.DeadLetterQueue(*queue))
+			}
+			break
+		}
+		err = remove(deadLetters, ids, "Purged", func(*rabbitmq.DeadLetter) error { return nil })
+	}
+	if err != nil {
+		log.Fatalf("%s failed: %v", command, err)
+	}
+}
+
+// deadLetterView is a dead letter as listed with -json
+type deadLetterView struct {
+	MessageID          string                 `json:"message_id"`
+	TransactionID      string                 `json:"transaction_id,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+	FailedAt           string                 `json:"failed_at,omitempty"`
+	RetryCount         int                    `json:"retry_count"`
+	OriginalExchange   string                 `json:"original_exchange,omitempty"`
+	OriginalRoutingKey string                 `json:"original_routing_key,omitempty"`
+	Headers            map[string]interface{} `json:"headers,omitempty"`
+	Body               json.RawMessage        `json:"body"`
+}
+
+func list(deadLetters *rabbitmq.DeadLetters, limit int, asJSON bool) error {
+	encoder := json.NewEncoder(os.Stdout)
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if !asJSON {
+		fmt.Fprintln(table, "MESSAGE ID\tTRANSACTION ID\tFAILED AT\tRETRIES\tREASON")
+	}
+
+	err := deadLetters.Visit(limit, func(letter *rabbitmq.DeadLetter) (bool, error) {
+		if !asJSON {
+			fmt.Fprintf(table, "%s\t%s\t%s\t%d\t%s\n",
+				letter.MessageID, transactionID(letter.Body), letter.FailedAt, letter.RetryCount, letter.Reason)
+			return false, nil
+		}
+
+		body := json.RawMessage(letter.Body)
+		if !json.Valid(body) {
+			body, _ = json.Marshal(string(letter.Body))
+		}
+		return false, encoder.Encode(deadLetterView{
+			MessageID:          letter.MessageID,
+			TransactionID:      transactionID(letter.Body),
+			Reason:             letter.Reason,
+			FailedAt:           letter.FailedAt,
+			RetryCount:         letter.RetryCount,
+			OriginalExchange:   letter.OriginalExchange,
+			OriginalRoutingKey: letter.OriginalRoutingKey,
+			Headers:            letter.Headers,
+			Body:               body,
+		})
+	})
+	if flushErr := table.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// remove applies fn to the dead letters with the given message ids, or to all of them if
+// ids is empty, and removes them from the queue
+func remove(deadLetters *rabbitmq.DeadLetters, ids []string, verb string, fn func(*rabbitmq.DeadLetter) error) error {
+	pending := map[string]bool{}
+	for _, id := range ids {
+		pending[id] = true
+	}
+
+	count := 0
+	err := deadLetters.Visit(0, func(letter *rabbitmq.DeadLetter) (bool, error) {
+		if len(ids) > 0 && !pending[letter.MessageID] {
+			return false, nil
+		}
+		if err := fn(letter); err != nil {
+			return false, fmt.Errorf("message %s: %w", letter.MessageID, err)
+		}
+		delete(pending, letter.MessageID)
+		count++
+
+		if len(ids) > 0 && len(pending) == 0 {
+			return true, rabbitmq.ErrStopVisit
+		}
+		return true, nil
+	})
+
+	log.Printf("%s %d dead letters", verb, count)
+	for id := range pending {
+		log.Printf("Message %s not found in the dead letter queue", id)
+	}
+	return err
+}
+
+// edit opens the body of a dead letter in $EDITOR and replays the edited body
+func edit(ctx context.Context, deadLetters *rabbitmq.DeadLetters, id string) error {
+	found := false
+	err := deadLetters.Visit(0, func(letter *rabbitmq.DeadLetter) (bool, error) {
+		if letter.MessageID != id {
+			return false, nil
+		}
+		found = true
+
+		body, changed, err := editBody(letter.Body)
+		if err != nil {
+			return false, err
+		}
+		if !changed {
+			log.Printf("Message %s unchanged, not replayed", id)
+			return false, rabbitmq.ErrStopVisit
+		}
+		if !json.Valid(body) {
+			return false, errors.New("edited body is not valid JSON")
+		}
+
+		if err := deadLetters.Replay(ctx, letter, body); err != nil {
+			return false, err
+		}
+		log.Printf("Replayed message %s with the edited body", id)
+		return true, rabbitmq.ErrStopVisit
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("message %s not found in the dead letter queue", id)
+	}
+	return err
+}
+
+// editBody opens body in $EDITOR and returns the saved file, compacted if it is JSON, and
+// whether it differs from what the editor was given
+func editBody(body []byte) ([]byte, bool, error) {
+	file, err := os.CreateTemp("", "dead-letter-*.json")
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.Remove(file.Name())
+
+	// Indent JSON bodies so they are easier to edit
+	written := body
+	var indented bytes.Buffer
+	if json.Indent(&indented, body, "", "  ") == nil {
+		written = indented.Bytes()
+	}
+	if _, err := file.Write(written); err != nil {
+		file.Close()
+		return nil, false, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, false, err
+	}
+
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, false, fmt.Errorf("editor: %w", err)
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return nil, false, err
+	}
+	if bytes.Equal(bytes.TrimSpace(edited), bytes.TrimSpace(written)) {
+		return body, false, nil
+	}
+
+	var compacted bytes.Buffer
+	if json.Compact(&compacted, edited) == nil {
+		edited = compacted.Bytes()
+	}
+	return edited, true, nil
+}
+
+// transactionID reads the transaction_id of a transaction message, if it has one
+func transactionID(body []byte) string {
+	var message struct {
+		TransactionID string `json:"transaction_id"`
+	}
+	json.Unmarshal(body, &message)
+	return message.TransactionID
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestEditBody(t *testing.T) {
+	body := []byte(`{"transaction_id":"TXN-1","value":"150.75"}`)
+
+	tests := []struct {
+		name     string
+		editor   string
+		expected string
+		changed  bool
+	}{
+		{"saved without changes", "true", string(body), false},
+		{"edited", "sed -i s/150.75/15.75/", `{"transaction_id":"TXN-1","value":"15.75"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", tt.editor)
+
+			edited, changed, err := editBody(body)
+			if err != nil {
+				t.Fatalf("editBody error: %v", err)
+			}
+			if changed != tt.changed || string(edited) != tt.expected {
+				t.Errorf("editBody = %s, changed %v, want %s, changed %v", edited, changed, tt.expected, tt.changed)
+			}
+		})
+	}
+}
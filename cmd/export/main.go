@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"register-payment/internal/config"
+	"register-payment/internal/dto"
+	"register-payment/internal/repository"
+	"register-payment/internal/service"
+	"register-payment/pkg/database"
+	"syscall"
+
+	"github.com/joho/godotenv"
+)
+
+// Exports transactions as CSV or NDJSON, e.g.
+//
+//	go run ./cmd/export -format csv -company COMP-1 -from 2025-08-01 -to 2025-08-08 -out week.csv
+func main() {
+	var query dto.ExportQuery
+	flag.StringVar(&query.Format, "format", "csv", "output format: csv or ndjson")
+	flag.StringVar(&query.Columns, "columns", "", "comma separated columns to export (default all)")
+	flag.StringVar(&query.ExternalCompanyID, "company", "", "only transactions of this external_company_id")
+	flag.StringVar(&query.Type, "type", "", "only transactions of this type: in or out")
+	flag.StringVar(&query.Currency, "currency", "", "only transactions in this currency")
+	flag.StringVar(&query.CreatedFrom, "from", "", "only transactions created at or after this date or RFC 3339 time (UTC)")
+	flag.StringVar(&query.CreatedTo, "to", "", "only transactions created before this date or RFC 3339 time (UTC)")
+	out := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	cfg := config.Load()
+
+	dbConfig := database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.DBName,
+		SSLMode:  cfg.Database.SSLMode,
+	}
+
+	db, err := database.NewPostgresDB(dbConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	exportService := service.NewExportService(repository.NewTransactionRepository(db.DB), service.Timeouts{
+		Export: cfg.Database.ExportTimeout,
+	})
+
+	export, err := exportService.NewExport(&query)
+	if err != nil {
+		log.Fatalf("Invalid export: %v", err)
+	}
+
+	var w io.WriteCloser = os.Stdout
+	if *out != "" {
+		if w, err = os.Create(*out); err != nil {
+			log.Fatalf("Failed to create %s: %v", *out, err)
+		}
+	}
+
+	// Interrupting cancels the query instead of leaving it running on the server
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := export.WriteTo(ctx, w); err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatalf("Failed to write export: %v", err)
+	}
+
+	if *out != "" {
+		log.Printf("Transactions exported to %s", *out)
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"register-payment/internal/config"
+	"register-payment/internal/dto"
+	"register-payment/internal/importer"
+	"register-payment/internal/repository"
+	"register-payment/internal/service"
+	"register-payment/pkg/database"
+	"register-payment/pkg/money"
+	"register-payment/pkg/rabbitmq"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Imports transactions from a CSV or NDJSON file, e.g.
+//
+//	go run ./cmd/import -mode insert -report errors.csv legacy.csv
+//	go run ./cmd/import -mode publish -dry-run legacy.ndjson
+func main() {
+	os.Exit(run())
+}
+
+// run imports the file and returns the exit code: 1 if any row was rejected or the import
+// failed, 2 for invalid usage. Returning instead of exiting lets deferred closes run.
+func run() int {
+	format := flag.String("format", "", "input format: csv or ndjson (default from the file extension)")
+	mode := flag.String("mode", "publish", "publish to RabbitMQ for the consumer, or insert directly into the database")
+	batchSize := flag.Int("batch-size", 100, "transactions per batch")
+	dryRun := flag.Bool("dry-run", false, "only read and validate, without publishing or inserting")
+	reportPath := flag.String("report", "", "write rejected rows as CSV to this file (default stderr)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] FILE\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 || (*mode != "publish" && *mode != "insert") || *batchSize <= 0 {
+		flag.Usage()
+		return 2
+	}
+	path := flag.Arg(0)
+	if *format == "" {
+		*format = importer.FormatFromPath(path)
+	}
+
+	input, err := os.Open(path)
+	if err != nil {
+		log.Printf("Failed to open %s: %v", path, err)
+		return 1
+	}
+	defer input.Close()
+
+	reader, err := importer.NewReader(input, *format)
+	if err != nil {
+		log.Printf("Failed to read %s: %v", path, err)
+		return 1
+	}
+
+	report, err := newReport(*reportPath)
+	if err != nil {
+		log.Printf("Failed to create report: %v", err)
+		return 1
+	}
+	defer report.Close()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+	cfg := config.Load()
+
+	var sink batchSink = dryRunSink{}
+	if !*dryRun {
+		var closeSink func()
+		if sink, closeSink, err = newSink(*mode, cfg); err != nil {
+			log.Print(err)
+			return 1
+		}
+		defer closeSink()
+	}
+
+	// Interrupting cancels the batch in flight and stops the import
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var summary summary
+	var batch []*importer.Row
+	flush := func() bool {
+		ok := sink.send(ctx, batch, report, &summary)
+		batch = batch[:0]
+		return ok
+	}
+
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Failed to read %s: %v", path, err)
+			summary.failed = true
+			break
+		}
+
+		summary.read++
+		if row.Err != nil {
+			report.reject(row, row.Err)
+			summary.rejected++
+			continue
+		}
+
+		batch = append(batch, row)
+		if len(batch) == *batchSize && !flush() {
+			break
+		}
+	}
+	if len(batch) > 0 && !summary.failed {
+		flush()
+	}
+
+	log.Printf("Read %d rows: %d %s, %d already registered, %d rejected",
+		summary.read, summary.accepted, sink.verb(), summary.duplicates, summary.rejected)
+
+	if err := report.Close(); err != nil {
+		log.Printf("Failed to write report: %v", err)
+		summary.failed = true
+	}
+	if summary.failed || summary.rejected > 0 {
+		return 1
+	}
+	return 0
+}
+
+type summary struct {
+	read, accepted, duplicates, rejected int
+	failed                               bool
+}
+
+// batchSink delivers a batch of valid rows, reporting the rows it cannot deliver.
+// It returns false when the import must stop.
+type batchSink interface {
+	send(ctx context.Context, batch []*importer.Row, report *report, summary *summary) bool
+	verb() string
+}
+
+func newSink(mode string, cfg *config.Config) (batchSink, func(), error) {
+	timeouts := service.Timeouts{Read: cfg.Database.ReadTimeout, Write: cfg.Database.WriteTimeout}
+
+	if mode == "insert" {
+		db, err := openDatabase(cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+
+		feeRounding, err := money.ParseRoundingMode(cfg.Ledger.FeeRounding)
+		if err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("invalid ledger fee rounding: %w", err)
+		}
+		postingRules := service.PostingRules{
+			FeeBasisPoints: int64(cfg.Ledger.FeeBasisPoints),
+			FeeRounding:    feeRounding,
+		}
+
+		transactionService := service.NewTransactionService(
+			repository.NewTransactionRepository(db.DB),
+			repository.NewBalanceRepository(db.DB),
+			repository.NewLedgerRepository(db.DB),
+			postingRules,
+			timeouts,
+		)
+		statusService := service.NewStatusService(repository.NewStatusRepository(db.DB), timeouts)
+		return &insertSink{transactions: transactionService, statuses: statusService}, func() { db.Close() }, nil
+	}
+
+	conn, err := rabbitmq.NewConnection(rabbitmq.Config{
+		URL:        cfg.RabbitMQ.URL,
+		MaxRetries: 5,
+		RetryDelay: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	sink := &publishSink{publisher: rabbitmq.NewPublisher(conn, cfg.RabbitMQ.Exchange)}
+
+	// Like the publisher API, status tracking needs the database; without it rows are still published
+	db, err := openDatabase(cfg)
+	if err != nil {
+		log.Printf("Warning: Failed to connect to database, transaction status tracking disabled: %v", err)
+		return sink, func() { conn.Close() }, nil
+	}
+	sink.statuses = service.NewStatusService(repository.NewStatusRepository(db.DB), timeouts)
+	return sink, func() { conn.Close(); db.Close() }, nil
+}
+
+func openDatabase(cfg *config.Config) (*database.DB, error) {
+	return database.NewPostgresDB(database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.DBName,
+		SSLMode:  cfg.Database.SSLMode,
+	})
+}
+
+type dryRunSink struct{}
+
+func (dryRunSink) send(ctx context.Context, batch []*importer.Row, report *report, summary *summary) bool {
+	summary.accepted += len(batch)
+	return true
+}
+
+func (dryRunSink) verb() string { return "valid (dry run)" }
+
+// insertSink stores each batch in one database transaction
+type insertSink struct {
+	transactions service.TransactionService
+	statuses     service.StatusService
+}
+
+func (s *insertSink) send(ctx context.Context, batch []*importer.Row, report *report, summary *summary) bool {
+	reqs := make([]*dto.TransactionRequest, len(batch))
+	for i, row := range batch {
+		reqs[i] = row.Request
+	}
+
+	results, err := s.transactions.CreateTransactions(ctx, reqs)
+	if err != nil {
+		// Nothing of the batch was stored; rerunning the import skips what earlier batches stored
+		for _, row := range batch {
+			report.reject(row, err)
+		}
+		summary.rejected += len(batch)
+		summary.failed = true
+		log.Printf("Batch failed, stopping import: %v", err)
+		return false
+	}
+
+	for i, err := range results {
+		switch {
+		case err == nil:
+			summary.accepted++
+		case errors.Is(err, service.ErrDuplicate):
+			summary.duplicates++
+		default:
+			report.reject(batch[i], err)
+			summary.rejected++
+			continue
+		}
+		if err := s.statuses.MarkRegistered(ctx, reqs[i].TransactionID); err != nil {
+			log.Printf("Failed to record status of transaction %s: %v", reqs[i].TransactionID, err)
+		}
+	}
+	return true
+}
+
+func (s *insertSink) verb() string { return "inserted" }
+
+// publishSink queues each batch for the consumer, waiting for the broker to confirm it.
+// Rows are marked queued before publishing, as the publisher API does; statuses is nil
+// when the database is unavailable.
+type publishSink struct {
+	publisher *rabbitmq.Publisher
+	statuses  service.StatusService
+}
+
+func (s *publishSink) send(ctx context.Context, batch []*importer.Row, report *report, summary *summary) bool {
+	messages := make([]interface{}, len(batch))
+	for i, row := range batch {
+		messages[i] = row.Request
+		s.recordStatus(row.Request.TransactionID, func(statuses service.StatusService) error {
+			return statuses.MarkQueued(ctx, row.Request.TransactionID)
+		})
+	}
+
+	ok := true
+	for i, err := range s.publisher.PublishJSONBatch(ctx, "transaction.register", messages) {
+		if err != nil {
+			transactionID := batch[i].Request.TransactionID
+			s.recordStatus(transactionID, func(statuses service.StatusService) error {
+				// The batch context may be the reason the publish failed
+				statusCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				return statuses.MarkRejected(statusCtx, transactionID, "failed to publish: "+err.Error())
+			})
+			report.reject(batch[i], err)
+			summary.rejected++
+			ok = false
+			continue
+		}
+		summary.accepted++
+	}
+	if !ok {
+		summary.failed = true
+		log.Printf("Publishing failed, stopping import")
+	}
+	return ok
+}
+
+func (s *publishSink) verb() string { return "published" }
+
+// recordStatus runs a status update if tracking is enabled, only logging failures
+func (s *publishSink) recordStatus(transactionID string, update func(service.StatusService) error) {
+	if s.statuses == nil {
+		return
+	}
+	if err := update(s.statuses); err != nil {
+		log.Printf("Failed to record status of transaction %s: %v", transactionID, err)
+	}
+}
+
+// report writes rejected rows as CSV: line, transaction_id and error
+type report struct {
+	writer *csv.Writer
+	closer io.Closer
+}
+
+func newReport(path string) (*report, error) {
+	var w io.WriteCloser = os.Stderr
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		w = file
+	}
+
+	r := &report{writer: csv.NewWriter(w), closer: w}
+	r.writer.Write([]string{"line", "transaction_id", "error"})
+	return r, nil
+}
+
+func (r *report) reject(row *importer.Row, err error) {
+	transactionID := ""
+	if row.Request != nil {
+		transactionID = row.Request.TransactionID
+	}
+	r.writer.Write([]string{fmt.Sprint(row.Line), transactionID, err.Error()})
+}
+
+// Close flushes the report; closing it again does nothing
+func (r *report) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	r.writer.Flush()
+	err := r.writer.Error()
+	if r.closer != os.Stderr {
+		if closeErr := r.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	r.closer = nil
+	return err
+}
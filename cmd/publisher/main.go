@@ -4,6 +4,9 @@ import (
 	"log"
 	"register-payment/internal/config"
 	"register-payment/internal/handler"
+	"register-payment/internal/repository"
+	"register-payment/internal/service"
+	"register-payment/pkg/database"
 	"register-payment/pkg/money"
 	"register-payment/pkg/rabbitmq"
 	"time"
@@ -33,54 +36,43 @@ func main() {
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
-	// Initialize publisher handler with nil publisher initially
-	var publisherHandler *handler.PublisherHandler
-
-	// Connect to RabbitMQ for publishing only (with retry in background)
-	rabbitConfig := rabbitmq.Config{
-		URL:        cfg.RabbitMQ.URL,
-		MaxRetries: 10,
-		RetryDelay: 10 * time.Second,
+	// Status tracking needs the database; without it transactions are still published
+	var statusService service.StatusService
+	dbConfig := database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.DBName,
+		SSLMode:  cfg.Database.SSLMode,
 	}
-
-	// Try to connect to RabbitMQ, but don't fail startup if unavailable
-	rabbitConn, err := rabbitmq.NewConnection(rabbitConfig)
+	db, err := database.NewPostgresDB(dbConfig)
 	if err != nil {
-		log.Printf("Warning: Failed to connect to RabbitMQ on startup: %v", err)
-		log.Println("Publisher will retry connection in background...")
-		publisherHandler = handler.NewPublisherHandler(nil) // Start with nil publisher
+		log.Printf("Warning: Failed to connect to database, transaction status tracking disabled: %v", err)
 	} else {
-		// Declare exchange and queue (idempotent operations)
-		err = rabbitConn.DeclareExchange(cfg.RabbitMQ.Exchange, "direct", true, false, false, false, nil)
-		if err != nil {
-			log.Printf("Warning: Failed to declare exchange: %v", err)
-		}
-
-		_, err = rabbitConn.DeclareQueue(cfg.RabbitMQ.Queue, true, false, false, false, nil)
-		if err != nil {
-			log.Printf("Warning: Failed to declare queue: %v", err)
+		defer db.Close()
+		timeouts := service.Timeouts{
+			Read:  cfg.Database.ReadTimeout,
+			Write: cfg.Database.WriteTimeout,
 		}
-
-		err = rabbitConn.BindQueue(cfg.RabbitMQ.Queue, "transaction.register", cfg.RabbitMQ.Exchange, false, nil)
-		if err != nil {
-			log.Printf("Warning: Failed to bind queue: %v", err)
-		}
-
-		// Initialize publisher
-		publisher := rabbitmq.NewPublisher(rabbitConn, cfg.RabbitMQ.Exchange)
-		publisherHandler = handler.NewPublisherHandler(publisher)
-		defer rabbitConn.Close()
+		statusService = service.NewStatusService(repository.NewStatusRepository(db.DB), timeouts)
 	}
 
+	// Requests fail with 503 until the background connection installs the publisher
+	publisherHandler := handler.NewPublisherHandler(nil, statusService)
+	go connectPublisher(cfg, publisherHandler)
+
 	// Publisher API routes
 	api := router.Group("/api/v1")
 	{
 		transactions := api.Group("/transactions")
 		{
-			// Only publishing endpoint - no database reads
 			transactions.POST("/", publisherHandler.PublishTransaction)
+			transactions.POST("/batch", publisherHandler.PublishTransactions)
+			transactions.GET("/:transaction_id/status", publisherHandler.GetTransactionStatus)
 		}
 		api.GET("/health", publisherHandler.HealthCheck)
+		api.GET("/ready", publisherHandler.ReadinessCheck)
 		api.GET("/metrics", publisherHandler.GetMetrics)
 	}
 
@@ -88,4 +80,42 @@ func main() {
 	if err := router.Run("0.0.0.0:" + cfg.Server.Port); err != nil {
 		log.Fatalf("Failed to start publisher API: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+// connectPublisher connects to RabbitMQ, retrying until the broker is reachable, declares
+// the topology and installs the publisher. Later outages are recovered by the connection.
+func connectPublisher(cfg *config.Config, publisherHandler *handler.PublisherHandler) {
+	rabbitConfig := rabbitmq.Config{
+		URL:        cfg.RabbitMQ.URL,
+		MaxRetries: 10,
+		RetryDelay: 10 * time.Second,
+	}
+
+	var rabbitConn *rabbitmq.Connection
+	for {
+		var err error
+		if rabbitConn, err = rabbitmq.NewConnection(rabbitConfig); err == nil {
+			break
+		}
+		log.Printf("Warning: %v, retrying in background...", err)
+	}
+
+	// Declare exchange and queue (idempotent operations)
+	err := rabbitConn.DeclareExchange(cfg.RabbitMQ.Exchange, "direct", true, false, false, false, nil)
+	if err != nil {
+		log.Printf("Warning: Failed to declare exchange: %v", err)
+	}
+
+	_, err = rabbitConn.DeclareQueue(cfg.RabbitMQ.Queue, true, false, false, false, nil)
+	if err != nil {
+		log.Printf("Warning: Failed to declare queue: %v", err)
+	}
+
+	err = rabbitConn.BindQueue(cfg.RabbitMQ.Queue, "transaction.register", cfg.RabbitMQ.Exchange, false, nil)
+	if err != nil {
+		log.Printf("Warning: Failed to bind queue: %v", err)
+	}
+
+	publisherHandler.SetPublisher(rabbitmq.NewPublisher(rabbitConn, cfg.RabbitMQ.Exchange))
+	log.Println("Publisher connected to RabbitMQ, accepting transactions")
+}
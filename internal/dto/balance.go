@@ -0,0 +1,25 @@
+package dto
+
+import (
+	"register-payment/pkg/money"
+	"time"
+)
+
+// BalanceResponse is the company's customer ledger account: incoming payments count net of
+// fees, so it can be lower than the transaction totals of the same company
+type BalanceResponse struct {
+	ExternalCompanyID string      `json:"external_company_id"`
+	Currency          string      `json:"currency"`
+	Balance           money.Money `json:"balance"`
+	AsOf              time.Time   `json:"as_of"`
+}
+
+// BalanceDriftResponse is a stored balance that disagrees with its customer ledger account;
+// Computed is the account's net-of-fee balance and Drift is Stored minus Computed
+type BalanceDriftResponse struct {
+	ExternalCompanyID string      `json:"external_company_id"`
+	Currency          string      `json:"currency"`
+	Stored            money.Money `json:"stored"`
+	Computed          money.Money `json:"computed"`
+	Drift             money.Money `json:"drift"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+// MaxBatchSize is the most transactions accepted in one batch request
+const MaxBatchSize = 500
+
+// BatchItemResult is the outcome of one item, in the order of the request
+type BatchItemResult struct {
+	Index         int    `json:"index"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+}
+
+type BatchTransactionResponse struct {
+	Results []BatchItemResult `json:"results"`
+	Queued  int               `json:"queued"`
+	Failed  int               `json:"failed"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+// ExportQuery selects the transactions to export, oldest first. Format is "csv" (default)
+// or "ndjson"; Columns is a comma separated subset of the export columns, all by default.
+type ExportQuery struct {
+	TransactionFilter
+	Format  string `form:"format" json:"format,omitempty"`
+	Columns string `form:"columns" json:"columns,omitempty"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"register-payment/pkg/money"
+	"time"
+)
+
+type JournalEntryResponse struct {
+	ID            int                   `json:"id"`
+	TransactionID string                `json:"transaction_id"`
+	Kind          string                `json:"kind"`
+	Description   string                `json:"description,omitempty"`
+	Lines         []JournalLineResponse `json:"lines"`
+	CreatedAt     time.Time             `json:"created_at"`
+}
+
+// JournalLineResponse is one side of an entry: positive amounts are debits, negative amounts credits
+type JournalLineResponse struct {
+	AccountCode string      `json:"account_code"`
+	AccountType string      `json:"account_type"`
+	Amount      money.Money `json:"amount"`
+	Currency    string      `json:"currency"`
+}
+
+type AccountBalanceResponse struct {
+	AccountCode string      `json:"account_code"`
+	Currency    string      `json:"currency"`
+	Balance     money.Money `json:"balance"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"register-payment/pkg/money"
+	"time"
+)
+
+// ReportQuery groups the filtered transactions by company, currency and time bucket.
+// Bucket is a calendar unit (hour, day, week, month, quarter, year) or a fixed duration
+// such as "15m" or "6h"; buckets start at local midnight or hour boundaries in TimeZone.
+type ReportQuery struct {
+	TransactionFilter
+	Bucket   string `form:"bucket" json:"bucket,omitempty"`
+	TimeZone string `form:"time_zone" json:"time_zone,omitempty"`
+}
+
+// PeriodTotalResponse sums transaction values as registered, before fees; Net is TotalIn
+// minus TotalOut, not the change of the company balance
+type PeriodTotalResponse struct {
+	ExternalCompanyID string      `json:"external_company_id"`
+	Currency          string      `json:"currency"`
+	PeriodStart       time.Time   `json:"period_start"`
+	Count             int         `json:"count"`
+	TotalIn           money.Money `json:"total_in"`
+	TotalOut          money.Money `json:"total_out"`
+	Net               money.Money `json:"net"`
+}
+
+type ReportResponse struct {
+	Bucket   string                 `json:"bucket"`
+	TimeZone string                 `json:"time_zone"`
+	Totals   []*PeriodTotalResponse `json:"totals"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+type TransactionStatusResponse struct {
+	TransactionID string    `json:"transaction_id"`
+	Status        string    `json:"status"`
+	Reason        string    `json:"reason,omitempty"`
+	Attempts      int       `json:"attempts"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
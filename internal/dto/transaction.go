@@ -1,29 +1,70 @@
 package dto
 
 import (
+	"encoding/json"
 	"register-payment/pkg/money"
 	"time"
 )
 
 type TransactionRequest struct {
-	TransactionID       string      `json:"transaction_id" binding:"required"`
-	Value              money.Money `json:"value" binding:"required"`
-	Type               string      `json:"type" binding:"required,oneof=in out"`
-	ExternalCompanyID  string      `json:"external_company_id" binding:"required"`
-	Description        string      `json:"description,omitempty"`
+	TransactionID     string      `json:"transaction_id" binding:"required"`
+	Value             money.Money `json:"value" binding:"required,money_positive"`
+	Currency          string      `json:"currency,omitempty" binding:"omitempty,len=3"`
+	Type              string      `json:"type" binding:"required,oneof=in out"`
+	ExternalCompanyID string      `json:"external_company_id" binding:"required"`
+	Description       string      `json:"description,omitempty"`
+}
+
+// UnmarshalJSON reads the value using the decimal places of the request's currency,
+// defaulting to money.DefaultCurrency when no currency is given. A value sent in
+// object form may carry its own currency, which must then agree with "currency".
+func (r *TransactionRequest) UnmarshalJSON(data []byte) error {
+	type alias TransactionRequest
+	aux := struct {
+		*alias
+		Value json.RawMessage `json:"value"`
+	}{alias: (*alias)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	currency := money.DefaultCurrency
+	if r.Currency != "" {
+		parsed, err := money.ParseCurrency(r.Currency)
+		if err != nil {
+			return err
+		}
+		currency = parsed
+	}
+
+	r.Value = money.NewMoneyFromMinorUnits(0, currency)
+	if len(aux.Value) > 0 {
+		if err := r.Value.UnmarshalJSON(aux.Value); err != nil {
+			return err
+		}
+	}
+
+	if r.Currency != "" && r.Value.Currency() != currency {
+		return &money.CurrencyMismatchError{Left: currency, Right: r.Value.Currency()}
+	}
+	r.Currency = r.Value.Currency().String()
+
+	return nil
 }
 
 type TransactionResponse struct {
-	ID                 int         `json:"id"`
-	TransactionID      string      `json:"transaction_id"`
-	Value              money.Money `json:"value"`
-	Type               string      `json:"type"`
-	ExternalCompanyID  string      `json:"external_company_id"`
-	Description        string      `json:"description,omitempty"`
-	CreatedAt          time.Time   `json:"created_at"`
-	UpdatedAt          time.Time   `json:"updated_at"`
+	ID                int         `json:"id"`
+	TransactionID     string      `json:"transaction_id"`
+	Value             money.Money `json:"value"`
+	Currency          string      `json:"currency"`
+	Type              string      `json:"type"`
+	ExternalCompanyID string      `json:"external_company_id"`
+	Description       string      `json:"description,omitempty"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
 }
 
 type QStashWebhookPayload struct {
 	Data TransactionRequest `json:"data"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,29 @@
+package dto
+
+// TransactionFilter selects transactions. Values are read in the filter's currency and
+// times are RFC 3339, or dates in the filter's time zone; empty fields do not filter.
+type TransactionFilter struct {
+	ExternalCompanyID string `form:"external_company_id" json:"external_company_id,omitempty"`
+	Type              string `form:"type" json:"type,omitempty"`
+	Currency          string `form:"currency" json:"currency,omitempty"`
+	MinValue          string `form:"min_value" json:"min_value,omitempty"`
+	MaxValue          string `form:"max_value" json:"max_value,omitempty"`
+	CreatedFrom       string `form:"created_from" json:"created_from,omitempty"`
+	CreatedTo         string `form:"created_to" json:"created_to,omitempty"`
+	Description       string `form:"description" json:"description,omitempty"`
+}
+
+// TransactionQuery filters and pages the transaction listing
+type TransactionQuery struct {
+	TransactionFilter
+	Cursor string `form:"cursor" json:"cursor,omitempty"`
+	Limit  int    `form:"limit" json:"limit,omitempty"`
+}
+
+// TransactionPage is one page of a listing. NextCursor is empty on the last page;
+// Total counts every transaction matching the filters across all pages.
+type TransactionPage struct {
+	Transactions []*TransactionResponse `json:"transactions"`
+	NextCursor   string                 `json:"next_cursor,omitempty"`
+	Total        int                    `json:"total"`
+}
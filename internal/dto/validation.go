@@ -0,0 +1,16 @@
+package dto
+
+import (
+	"register-payment/pkg/money"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator returns a validator that applies the same `binding` tags as gin's
+// ShouldBindJSON, so messages read from the queue are held to the HTTP rules
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	money.RegisterValidators(v)
+	return v
+}
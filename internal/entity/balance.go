@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"register-payment/pkg/money"
+	"time"
+)
+
+// Balance is what is owed to a company in a currency: the balance of its customer ledger
+// account, so incoming payments count net of fees
+type Balance struct {
+	ExternalCompanyID string         `db:"external_company_id" json:"external_company_id"`
+	Currency          money.Currency `db:"currency" json:"currency"`
+	Balance           money.Money    `db:"balance" json:"balance"`
+	UpdatedAt         time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// BalanceDrift is a stored balance that disagrees with the lines of its customer account
+type BalanceDrift struct {
+	ExternalCompanyID string         `json:"external_company_id"`
+	Currency          money.Currency `json:"currency"`
+	Stored            money.Money    `json:"stored"`
+	Computed          money.Money    `json:"computed"`
+}
@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"register-payment/pkg/money"
+	"time"
+)
+
+const (
+	AccountTypeAsset     = "asset"
+	AccountTypeLiability = "liability"
+	AccountTypeRevenue   = "revenue"
+	AccountTypeExpense   = "expense"
+)
+
+const (
+	JournalKindPayment  = "payment"
+	JournalKindReversal = "reversal"
+)
+
+// System account codes; each company additionally has its own customer account
+const (
+	AccountCodeClearing   = "clearing"
+	AccountCodeFeeRevenue = "fee_revenue"
+)
+
+// ErrUnbalancedEntry is returned when journal lines do not sum to zero
+var ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+
+// CustomerAccountCode returns the code of the account holding what is owed to a company
+func CustomerAccountCode(externalCompanyID string) string {
+	return "customer:" + externalCompanyID
+}
+
+type Account struct {
+	ID                int            `db:"id" json:"id"`
+	Code              string         `db:"code" json:"code"`
+	Type              string         `db:"type" json:"type"`
+	ExternalCompanyID string         `db:"external_company_id" json:"external_company_id,omitempty"`
+	Currency          money.Currency `db:"currency" json:"currency"`
+	CreatedAt         time.Time      `db:"created_at" json:"created_at"`
+}
+
+type JournalEntry struct {
+	ID            int           `db:"id" json:"id"`
+	TransactionID string        `db:"transaction_id" json:"transaction_id"`
+	Kind          string        `db:"kind" json:"kind"`
+	Description   string        `db:"description" json:"description"`
+	Lines         []JournalLine `db:"-" json:"lines"`
+	CreatedAt     time.Time     `db:"created_at" json:"created_at"`
+}
+
+// JournalLine moves Amount into an account: positive amounts are debits, negative amounts credits
+type JournalLine struct {
+	ID                int         `db:"id" json:"id"`
+	JournalEntryID    int         `db:"journal_entry_id" json:"journal_entry_id"`
+	AccountCode       string      `db:"account_code" json:"account_code"`
+	AccountType       string      `db:"account_type" json:"account_type"`
+	ExternalCompanyID string      `db:"external_company_id" json:"external_company_id,omitempty"`
+	Amount            money.Money `db:"amount" json:"amount"`
+}
+
+// Validate checks that the entry has non-zero lines in a single currency that sum to zero
+func (e *JournalEntry) Validate() error {
+	if len(e.Lines) < 2 {
+		return fmt.Errorf("%w: needs at least two lines, got %d", ErrUnbalancedEntry, len(e.Lines))
+	}
+
+	sum := money.NewMoneyFromMinorUnits(0, e.Lines[0].Amount.Currency())
+	for _, line := range e.Lines {
+		if line.Amount.IsZero() {
+			return fmt.Errorf("%w: zero amount on account %s", ErrUnbalancedEntry, line.AccountCode)
+		}
+		var err error
+		if sum, err = sum.Add(line.Amount); err != nil {
+			return fmt.Errorf("%w: %v", ErrUnbalancedEntry, err)
+		}
+	}
+
+	if !sum.IsZero() {
+		return fmt.Errorf("%w: lines sum to %s", ErrUnbalancedEntry, sum)
+	}
+	return nil
+}
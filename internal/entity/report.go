@@ -0,0 +1,16 @@
+package entity
+
+import (
+	"register-payment/pkg/money"
+	"time"
+)
+
+// PeriodTotal aggregates a company's transactions in one currency over one time bucket
+type PeriodTotal struct {
+	ExternalCompanyID string         `json:"external_company_id"`
+	Currency          money.Currency `json:"currency"`
+	PeriodStart       time.Time      `json:"period_start"`
+	Count             int            `json:"count"`
+	TotalIn           money.Money    `json:"total_in"`
+	TotalOut          money.Money    `json:"total_out"`
+}
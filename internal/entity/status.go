@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// Statuses a transaction goes through from the publisher to the database
+const (
+	StatusQueued       = "queued"
+	StatusProcessing   = "processing"
+	StatusRegistered   = "registered"
+	StatusRejected     = "rejected"
+	StatusDeadLettered = "dead_lettered"
+)
+
+// TransactionStatus tracks the processing of a transaction_id. Attempts counts
+// the deliveries the consumer started processing.
+type TransactionStatus struct {
+	TransactionID string    `db:"transaction_id" json:"transaction_id"`
+	Status        string    `db:"status" json:"status"`
+	Reason        string    `db:"reason" json:"reason,omitempty"`
+	Attempts      int       `db:"attempts" json:"attempts"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -5,13 +5,27 @@ import (
 	"time"
 )
 
+const (
+	TransactionTypeIn  = "in"
+	TransactionTypeOut = "out"
+)
+
 type Transaction struct {
-	ID                int         `db:"id" json:"id"`
-	TransactionID     string      `db:"transaction_id" json:"transaction_id"`
-	Value             money.Money `db:"value" json:"value"`
-	Type              string      `db:"type" json:"type"`
-	ExternalCompanyID string      `db:"external_company_id" json:"external_company_id"`
-	Description       string      `db:"description" json:"description"`
-	CreatedAt         time.Time   `db:"created_at" json:"created_at"`
-	UpdatedAt         time.Time   `db:"updated_at" json:"updated_at"`
-}
\ No newline at end of file
+	ID                int            `db:"id" json:"id"`
+	TransactionID     string         `db:"transaction_id" json:"transaction_id"`
+	Value             money.Money    `db:"value" json:"value"`
+	Currency          money.Currency `db:"currency" json:"currency"`
+	Type              string         `db:"type" json:"type"`
+	ExternalCompanyID string         `db:"external_company_id" json:"external_company_id"`
+	Description       string         `db:"description" json:"description"`
+	CreatedAt         time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// SignedValue returns the value's effect on the company balance: positive for "in", negative for "out"
+func (t *Transaction) SignedValue() money.Money {
+	if t.Type == TransactionTypeOut {
+		return t.Value.Negate()
+	}
+	return t.Value
+}
@@ -3,25 +3,35 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"register-payment/internal/dto"
 	"register-payment/internal/service"
+	"register-payment/pkg/rabbitmq"
+	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/go-playground/validator/v10"
 )
 
 type ConsumerHandler struct {
 	transactionService service.TransactionService
-	metrics           ConsumerMetrics
-	startTime         time.Time
+	statuses           service.StatusService
+	validate           *validator.Validate
+	metrics            ConsumerMetrics
+	startTime          time.Time
+
+	// errorsMu guards metrics.ProcessingErrors, as messages are processed concurrently
+	errorsMu sync.Mutex
 }
 
 type ConsumerMetrics struct {
-	TotalProcessed     int64 `json:"total_processed"`
-	SuccessCount       int64 `json:"success_count"`
-	ErrorCount         int64 `json:"error_count"`
-	LastProcessedTime  int64 `json:"last_processed_time"`
-	ProcessingErrors   []ProcessingError `json:"recent_errors"`
+	TotalProcessed    int64             `json:"total_processed"`
+	SuccessCount      int64             `json:"success_count"`
+	ErrorCount        int64             `json:"error_count"`
+	LastProcessedTime int64             `json:"last_processed_time"`
+	ProcessingErrors  []ProcessingError `json:"recent_errors"`
 }
 
 type ProcessingError struct {
@@ -30,9 +40,11 @@ type ProcessingError struct {
 	MessageData string    `json:"message_data,omitempty"`
 }
 
-func NewConsumerHandler(transactionService service.TransactionService) *ConsumerHandler {
+func NewConsumerHandler(transactionService service.TransactionService, statuses service.StatusService) *ConsumerHandler {
 	return &ConsumerHandler{
 		transactionService: transactionService,
+		statuses:           statuses,
+		validate:           dto.NewValidator(),
 		metrics: ConsumerMetrics{
 			ProcessingErrors: make([]ProcessingError, 0, 10), // Keep last 10 errors
 		},
@@ -50,51 +62,100 @@ func (h *ConsumerHandler) ProcessTransaction(ctx context.Context, body []byte) e
 		atomic.AddInt64(&h.metrics.ErrorCount, 1)
 		h.addError("Failed to unmarshal message", err.Error(), string(body))
 		log.Printf("Failed to unmarshal transaction message: %v", err)
-		return err
+		if req.TransactionID != "" {
+			h.logStatusError(req.TransactionID, h.statuses.MarkDeadLettered(ctx, req.TransactionID, "malformed message: "+err.Error()))
+		}
+		return rabbitmq.Reject(err) // Redelivering cannot fix a malformed message
 	}
 
-	// Validate the transaction request
-	if req.TransactionID == "" {
+	// Validate the transaction request with the same rules as the publisher API
+	if err := h.validate.Struct(&req); err != nil {
 		atomic.AddInt64(&h.metrics.ErrorCount, 1)
-		h.addError("Invalid transaction", "Transaction ID is required", string(body))
-		log.Printf("Invalid transaction: missing transaction ID")
-		return nil // Don't requeue invalid messages
+		h.addError("Invalid transaction", err.Error(), string(body))
+		log.Printf("Invalid transaction %s: %v", req.TransactionID, err)
+		if req.TransactionID != "" {
+			h.logStatusError(req.TransactionID, h.statuses.MarkRejected(ctx, req.TransactionID, err.Error()))
+		}
+		return rabbitmq.Reject(err)
 	}
 
-	if req.Value.IsZero() {
-		atomic.AddInt64(&h.metrics.ErrorCount, 1)
-		h.addError("Invalid transaction", "Transaction value must be greater than zero", string(body))
-		log.Printf("Invalid transaction: zero value for transaction %s", req.TransactionID)
-		return nil // Don't requeue invalid messages
-	}
+	h.logStatusError(req.TransactionID, h.statuses.MarkProcessing(ctx, req.TransactionID))
 
 	// Process the transaction
-	transaction, err := h.transactionService.CreateTransaction(&req)
-	if err != nil {
+	transaction, err := h.transactionService.CreateTransaction(ctx, &req)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrDuplicate):
+		// Redelivery of a message we already stored: acknowledge it again
+		atomic.AddInt64(&h.metrics.SuccessCount, 1)
+		log.Printf("Transaction %s already registered (ID: %d), acknowledging duplicate", transaction.TransactionID, transaction.ID)
+		h.logStatusError(req.TransactionID, h.statuses.MarkRegistered(ctx, req.TransactionID))
+		return nil
+	case errors.Is(err, service.ErrConflict), errors.Is(err, service.ErrValidation):
+		atomic.AddInt64(&h.metrics.ErrorCount, 1)
+		h.addError("Rejected transaction", err.Error(), string(body))
+		log.Printf("Rejected transaction %s: %v", req.TransactionID, err)
+		h.logStatusError(req.TransactionID, h.statuses.MarkRejected(ctx, req.TransactionID, err.Error()))
+		return rabbitmq.Reject(err) // Requeueing cannot resolve it
+	default:
+		// Transient failures and anything unclassified are retried
 		atomic.AddInt64(&h.metrics.ErrorCount, 1)
 		h.addError("Database error", err.Error(), req.TransactionID)
 		log.Printf("Failed to create transaction %s: %v", req.TransactionID, err)
-		return err // This will cause the message to be requeued
+		return rabbitmq.Retry(err)
 	}
 
 	atomic.AddInt64(&h.metrics.SuccessCount, 1)
-	log.Printf("Successfully processed transaction %s (ID: %d, Value: %s, Type: %s)", 
-		transaction.TransactionID, 
-		transaction.ID, 
-		transaction.Value.String(), 
+	log.Printf("Successfully processed transaction %s (ID: %d, Value: %s, Type: %s)",
+		transaction.TransactionID,
+		transaction.ID,
+		transaction.Value.String(),
 		transaction.Type)
+	h.logStatusError(req.TransactionID, h.statuses.MarkRegistered(ctx, req.TransactionID))
 
 	return nil
 }
 
+// CompanyOrderingKey is the external_company_id of a transaction message, so the consumer
+// processes the transactions of each company in order
+func CompanyOrderingKey(body []byte) string {
+	var req struct {
+		ExternalCompanyID string `json:"external_company_id"`
+	}
+	if json.Unmarshal(body, &req) != nil {
+		return ""
+	}
+	return req.ExternalCompanyID
+}
+
+// RetriesExhausted records that a message failed all its retries and is being dead-lettered
+func (h *ConsumerHandler) RetriesExhausted(ctx context.Context, body []byte, err error) {
+	var req dto.TransactionRequest
+	if json.Unmarshal(body, &req) != nil || req.TransactionID == "" {
+		return
+	}
+	h.logStatusError(req.TransactionID, h.statuses.MarkDeadLettered(ctx, req.TransactionID, "retries exhausted: "+err.Error()))
+}
+
+// logStatusError logs a failed status update. The message outcome does not depend on it:
+// the transaction itself is what must be processed reliably.
+func (h *ConsumerHandler) logStatusError(transactionID string, err error) {
+	if err != nil {
+		log.Printf("Failed to record status of transaction %s: %v", transactionID, err)
+	}
+}
+
 // GetMetrics returns processing metrics
 func (h *ConsumerHandler) GetMetrics() ConsumerMetrics {
+	h.errorsMu.Lock()
+	defer h.errorsMu.Unlock()
+
 	return ConsumerMetrics{
 		TotalProcessed:    atomic.LoadInt64(&h.metrics.TotalProcessed),
 		SuccessCount:      atomic.LoadInt64(&h.metrics.SuccessCount),
 		ErrorCount:        atomic.LoadInt64(&h.metrics.ErrorCount),
 		LastProcessedTime: atomic.LoadInt64(&h.metrics.LastProcessedTime),
-		ProcessingErrors:  h.metrics.ProcessingErrors,
+		ProcessingErrors:  append([]ProcessingError(nil), h.metrics.ProcessingErrors...),
 	}
 }
 
@@ -110,7 +171,7 @@ func (h *ConsumerHandler) IsHealthy() bool {
 		// Haven't processed any messages yet, but that might be normal
 		return time.Since(h.startTime) < 5*time.Minute
 	}
-	
+
 	// Considered healthy if we processed a message in the last 5 minutes
 	return time.Since(time.Unix(lastProcessed, 0)) < 5*time.Minute
 }
@@ -122,9 +183,12 @@ func (h *ConsumerHandler) addError(errorType, errorMsg, context string) {
 		MessageData: context,
 	}
 
+	h.errorsMu.Lock()
+	defer h.errorsMu.Unlock()
+
 	// Keep only the last 10 errors (simple circular buffer)
 	if len(h.metrics.ProcessingErrors) >= 10 {
 		h.metrics.ProcessingErrors = h.metrics.ProcessingErrors[1:]
 	}
 	h.metrics.ProcessingErrors = append(h.metrics.ProcessingErrors, error)
-}
\ No newline at end of file
+}
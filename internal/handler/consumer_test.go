@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"register-payment/internal/entity"
+	"register-payment/internal/service"
+	"register-payment/pkg/rabbitmq"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// fakeStatusRepository keeps every status recorded per transaction_id
+type fakeStatusRepository struct {
+	history map[string][]entity.TransactionStatus
+}
+
+func (r *fakeStatusRepository) Save(ctx context.Context, status *entity.TransactionStatus) error {
+	r.history[status.TransactionID] = append(r.history[status.TransactionID], *status)
+	return nil
+}
+
+func (r *fakeStatusRepository) Get(ctx context.Context, transactionID string) (*entity.TransactionStatus, error) {
+	history := r.history[transactionID]
+	if len(history) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &history[len(history)-1], nil
+}
+
+func (r *fakeStatusRepository) statuses(transactionID string) []string {
+	var statuses []string
+	for _, status := range r.history[transactionID] {
+		statuses = append(statuses, status.Status)
+	}
+	return statuses
+}
+
+func newTestConsumer(repo *fakeRepository) (*ConsumerHandler, *fakeStatusRepository) {
+	statusRepo := &fakeStatusRepository{history: map[string][]entity.TransactionStatus{}}
+	transactionService := service.NewTransactionService(repo, nil, nil, service.PostingRules{}, service.Timeouts{})
+	return NewConsumerHandler(transactionService, service.NewStatusService(statusRepo, service.Timeouts{})), statusRepo
+}
+
+func TestProcessTransactionOutcomes(t *testing.T) {
+	tests := []struct {
+		name          string
+		transactionID string
+		body          string
+		repoErr       error
+		reject        bool
+		retry         bool
+		statuses      []string
+	}{
+		{"registered", "TXN-9", `{"transaction_id":"TXN-9","value":"10.00","type":"in","external_company_id":"COMP-1"}`,
+			nil, false, false, []string{"processing", "registered"}},
+		{"duplicate", "TXN-1", `{"transaction_id":"TXN-1","value":"150.75","type":"in","external_company_id":"COMP-1"}`,
+			nil, false, false, []string{"processing", "registered"}},
+		{"conflict", "TXN-1", `{"transaction_id":"TXN-1","value":"1.00","type":"in","external_company_id":"COMP-1"}`,
+			nil, true, false, []string{"processing", "rejected"}},
+		{"invalid", "TXN-9", `{"transaction_id":"TXN-9","value":"0.00","type":"in","external_company_id":"COMP-1"}`,
+			nil, true, false, []string{"rejected"}},
+		{"malformed", "TXN-9", `{"transaction_id":"TXN-9","value":"abc"}`,
+			nil, true, false, []string{"dead_lettered"}},
+		{"transient", "TXN-9", `{"transaction_id":"TXN-9","value":"10.00","type":"in","external_company_id":"COMP-1"}`,
+			&pq.Error{Code: "40P01"}, false, true, []string{"processing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := seededRepository()
+			repo.err = tt.repoErr
+			consumer, statusRepo := newTestConsumer(repo)
+
+			err := consumer.ProcessTransaction(context.Background(), []byte(tt.body))
+			if got := errors.Is(err, rabbitmq.ErrReject); got != tt.reject {
+				t.Errorf("rejected = %t, want %t (err: %v)", got, tt.reject, err)
+			}
+			if got := errors.Is(err, rabbitmq.ErrRetry); got != tt.retry {
+				t.Errorf("retried = %t, want %t (err: %v)", got, tt.retry, err)
+			}
+
+			got := statusRepo.statuses(tt.transactionID)
+			if len(got) != len(tt.statuses) {
+				t.Fatalf("statuses = %v, want %v", got, tt.statuses)
+			}
+			for i := range got {
+				if got[i] != tt.statuses[i] {
+					t.Fatalf("statuses = %v, want %v", got, tt.statuses)
+				}
+			}
+		})
+	}
+}
+
+func TestRetriesExhaustedMarksDeadLettered(t *testing.T) {
+	consumer, statusRepo := newTestConsumer(seededRepository())
+	body := []byte(`{"transaction_id":"TXN-9","value":"10.00","type":"in","external_company_id":"COMP-1"}`)
+
+	consumer.RetriesExhausted(context.Background(), body, errors.New("database unavailable"))
+	consumer.RetriesExhausted(context.Background(), []byte(`not json`), errors.New("database unavailable"))
+
+	if got := statusRepo.statuses("TXN-9"); len(got) != 1 || got[0] != "dead_lettered" {
+		t.Errorf("statuses = %v, want [dead_lettered]", got)
+	}
+}
+
+func TestCompanyOrderingKey(t *testing.T) {
+	body := []byte(`{"transaction_id":"TXN-9","value":"10.00","type":"in","external_company_id":"COMP-1"}`)
+	if got := CompanyOrderingKey(body); got != "COMP-1" {
+		t.Errorf("CompanyOrderingKey = %q, want COMP-1", got)
+	}
+	if got := CompanyOrderingKey([]byte(`not json`)); got != "" {
+		t.Errorf("CompanyOrderingKey of a malformed message = %q, want empty", got)
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"register-payment/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusForError maps a service error to the HTTP status returned to API clients
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrDuplicate):
+		return http.StatusOK
+	case errors.Is(err, service.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrTransient):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondError writes err as a JSON error response. Internal errors are not detailed to the client.
+func respondError(c *gin.Context, err error) {
+	status := statusForError(err)
+	if status == http.StatusInternalServerError {
+		c.JSON(status, gin.H{"error": "Internal server error"})
+		return
+	}
+	c.JSON(status, gin.H{
+		"error":   http.StatusText(status),
+		"details": err.Error(),
+	})
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"register-payment/internal/dto"
+	"register-payment/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler streams transaction exports over HTTP
+type ExportHandler struct {
+	exportService service.ExportService
+}
+
+func NewExportHandler(exportService service.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// RegisterRoutes adds the export routes to the group
+func (h *ExportHandler) RegisterRoutes(api *gin.RouterGroup) {
+	api.GET("/transactions/export", h.ExportTransactions)
+}
+
+// ExportTransactions downloads the matching transactions as CSV or NDJSON,
+// e.g. /transactions/export?format=csv&columns=transaction_id,value,currency&created_from=2025-08-01
+func (h *ExportHandler) ExportTransactions(c *gin.Context) {
+	var query dto.ExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+
+	export, err := h.exportService.NewExport(&query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="transactions.`+export.Format()+`"`)
+
+	err = export.WriteTo(c.Request.Context(), c.Writer)
+	if err == nil {
+		return
+	}
+	if !c.Writer.Written() {
+		// Rows are buffered, so failures before the first flush can still be reported
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		respondError(c, err)
+		return
+	}
+	// The status went out with the first rows; all that is left is to cut the download short
+	log.Printf("Transaction export interrupted: %v", err)
+	c.Abort()
+}
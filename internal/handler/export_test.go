@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"register-payment/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newExportRouter(repo *fakeRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewExportHandler(service.NewExportService(repo, service.Timeouts{})).RegisterRoutes(router.Group("/api/v1"))
+	return router
+}
+
+func TestExportHandlerFormats(t *testing.T) {
+	repo := seededRepository()
+	repo.transactions[1].Description = `Invoice "42", paid`
+	router := newExportRouter(repo)
+
+	rec := serve(router, http.MethodGet, "/api/v1/transactions/export?columns=transaction_id,value,currency,description&external_company_id=COMP-1", "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("csv export = %d %s: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	expected := "transaction_id,value,currency,description\n" +
+		"TXN-1,150.75,BRL,\"Invoice \"\"42\"\", paid\"\n" +
+		"TXN-2,50.00,BRL,\n"
+	if rec.Body.String() != expected {
+		t.Errorf("csv export =\n%s\nwant\n%s", rec.Body.String(), expected)
+	}
+
+	rec = serve(router, http.MethodGet, "/api/v1/transactions/export?format=ndjson&columns=transaction_id,value,type&type=in", "")
+	expected = `{"transaction_id":"TXN-1","value":"150.75","type":"in"}` + "\n" +
+		`{"transaction_id":"TXN-3","value":"1.00","type":"in"}` + "\n"
+	if rec.Code != http.StatusOK || rec.Body.String() != expected {
+		t.Errorf("ndjson export = %d\n%s\nwant\n%s", rec.Code, rec.Body.String(), expected)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="transactions.ndjson"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
+
+func TestExportHandlerErrors(t *testing.T) {
+	router := newExportRouter(seededRepository())
+	for _, target := range []string{
+		"/api/v1/transactions/export?format=xlsx",
+		"/api/v1/transactions/export?columns=transaction_id,balance",
+		"/api/v1/transactions/export?created_from=last-week",
+	} {
+		if rec := serve(router, http.MethodGet, target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+
+	repo := seededRepository()
+	repo.err = context.DeadlineExceeded
+	rec := serve(newExportRouter(repo), http.MethodGet, "/api/v1/transactions/export", "")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Content-Disposition") != "" {
+		t.Errorf("failed export = %d with Content-Disposition %q, want a plain %d", rec.Code, rec.Header().Get("Content-Disposition"), http.StatusServiceUnavailable)
+	}
+}
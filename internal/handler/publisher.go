@@ -2,17 +2,30 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"register-payment/internal/dto"
+	"register-payment/internal/entity"
+	"register-payment/internal/service"
 	"register-payment/pkg/rabbitmq"
 	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
+// statusUpdateTimeout bounds the status update recorded after a failed publish
+const statusUpdateTimeout = 5 * time.Second
+
 type PublisherHandler struct {
-	publisher *rabbitmq.Publisher
+	// publisher is nil until the broker connection is ready; see SetPublisher
+	publisher atomic.Pointer[rabbitmq.Publisher]
+	statuses  service.StatusService
+	validate  *validator.Validate
 	metrics   PublisherMetrics
 }
 
@@ -23,11 +36,24 @@ type PublisherMetrics struct {
 	LastRequestTime int64 `json:"last_request_time"`
 }
 
-func NewPublisherHandler(publisher *rabbitmq.Publisher) *PublisherHandler {
-	return &PublisherHandler{
-		publisher: publisher,
-		metrics:   PublisherMetrics{},
+// NewPublisherHandler creates the handler; statuses may be nil when the database is
+// unavailable, in which case transactions are published without status tracking
+func NewPublisherHandler(publisher *rabbitmq.Publisher, statuses service.StatusService) *PublisherHandler {
+	h := &PublisherHandler{
+		statuses: statuses,
+		validate: dto.NewValidator(),
+		metrics:  PublisherMetrics{},
+	}
+	if publisher != nil {
+		h.publisher.Store(publisher)
 	}
+	return h
+}
+
+// SetPublisher installs the publisher once the broker connection is ready, so a handler
+// created while RabbitMQ was down starts publishing without a restart
+func (h *PublisherHandler) SetPublisher(publisher *rabbitmq.Publisher) {
+	h.publisher.Store(publisher)
 }
 
 // PublishTransaction publishes a transaction to RabbitMQ for processing
@@ -45,24 +71,32 @@ func (h *PublisherHandler) PublishTransaction(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	// Check if publisher is available
-	if h.publisher == nil {
+	publisher := h.publisher.Load()
+	if publisher == nil {
 		atomic.AddInt64(&h.metrics.ErrorCount, 1)
 		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Message queue is currently unavailable",
+			"error":  "Message queue is currently unavailable",
 			"status": "service_unavailable",
 		})
 		return
 	}
 
+	// Record the status before publishing so the consumer's updates always come after it
+	h.recordStatus(req.TransactionID, func(statuses service.StatusService) error {
+		return statuses.MarkQueued(ctx, req.TransactionID)
+	})
+
 	// Publish to RabbitMQ
-	if err := h.publisher.PublishJSON(ctx, "transaction.register", req); err != nil {
+	if err := publisher.PublishJSON(ctx, "transaction.register", req); err != nil {
 		atomic.AddInt64(&h.metrics.ErrorCount, 1)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to publish transaction",
+		h.markPublishFailed(req.TransactionID, err)
+		status, message := publishFailure(err)
+		c.JSON(status, gin.H{
+			"error": message,
 		})
 		return
 	}
@@ -77,10 +111,200 @@ func (h *PublisherHandler) PublishTransaction(c *gin.Context) {
 	})
 }
 
-// HealthCheck returns the health status of the publisher service
+// PublishTransactions publishes a JSON array of transactions. Items are validated one by one
+// and the valid ones are published together, waiting for the broker to confirm them. The
+// response reports each item as queued or rejected: 202 if all were queued, 207 otherwise.
+func (h *PublisherHandler) PublishTransactions(c *gin.Context) {
+	atomic.AddInt64(&h.metrics.TotalRequests, 1)
+	atomic.StoreInt64(&h.metrics.LastRequestTime, time.Now().Unix())
+
+	var items []json.RawMessage
+	if err := json.NewDecoder(c.Request.Body).Decode(&items); err != nil {
+		atomic.AddInt64(&h.metrics.ErrorCount, 1)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+	if len(items) == 0 || len(items) > dto.MaxBatchSize {
+		atomic.AddInt64(&h.metrics.ErrorCount, 1)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": fmt.Sprintf("expected between 1 and %d transactions, got %d", dto.MaxBatchSize, len(items)),
+		})
+		return
+	}
+
+	publisher := h.publisher.Load()
+	if publisher == nil {
+		atomic.AddInt64(&h.metrics.ErrorCount, 1)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":  "Message queue is currently unavailable",
+			"status": "service_unavailable",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	reqs, results := decodeBatch(h.validate, items)
+
+	var indexes []int
+	var messages []interface{}
+	for i, req := range reqs {
+		if req == nil {
+			continue
+		}
+		h.recordStatus(req.TransactionID, func(statuses service.StatusService) error {
+			return statuses.MarkQueued(ctx, req.TransactionID)
+		})
+		indexes = append(indexes, i)
+		messages = append(messages, req)
+	}
+
+	if len(messages) > 0 {
+		for j, err := range publisher.PublishJSONBatch(ctx, "transaction.register", messages) {
+			if err == nil {
+				continue
+			}
+			h.markPublishFailed(reqs[indexes[j]].TransactionID, err)
+			_, message := publishFailure(err)
+			results[indexes[j]].Status = entity.StatusRejected
+			results[indexes[j]].Error = message
+		}
+	}
+
+	response := dto.BatchTransactionResponse{Results: results}
+	for _, result := range results {
+		if result.Status == entity.StatusQueued {
+			response.Queued++
+		} else {
+			response.Failed++
+		}
+	}
+	atomic.AddInt64(&h.metrics.SuccessCount, int64(response.Queued))
+	atomic.AddInt64(&h.metrics.ErrorCount, int64(response.Failed))
+
+	status := http.StatusAccepted
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, response)
+}
+
+// decodeBatch decodes and validates each item on its own. The request of an invalid item is
+// nil and its result rejected; a transaction_id repeated in the batch is rejected after its
+// first occurrence.
+func decodeBatch(validate *validator.Validate, items []json.RawMessage) ([]*dto.TransactionRequest, []dto.BatchItemResult) {
+	reqs := make([]*dto.TransactionRequest, len(items))
+	results := make([]dto.BatchItemResult, len(items))
+	seen := map[string]int{}
+
+	for i, item := range items {
+		var req dto.TransactionRequest
+		err := json.Unmarshal(item, &req)
+		if err == nil {
+			err = validate.Struct(&req)
+		}
+		if first, ok := seen[req.TransactionID]; err == nil && ok {
+			err = fmt.Errorf("transaction_id %s already appears at index %d", req.TransactionID, first)
+		}
+
+		results[i] = dto.BatchItemResult{Index: i, TransactionID: req.TransactionID, Status: entity.StatusQueued}
+		if err != nil {
+			results[i].Status = entity.StatusRejected
+			results[i].Error = err.Error()
+			continue
+		}
+		seen[req.TransactionID] = i
+		reqs[i] = &req
+	}
+
+	return reqs, results
+}
+
+// publishFailure maps a publish error to a response. An unroutable message means the
+// broker topology is wrong; a nack, closed channel or missing confirmation that the
+// broker is unavailable, so the client may retry.
+func publishFailure(err error) (int, string) {
+	switch {
+	case errors.Is(err, rabbitmq.ErrUnroutable):
+		return http.StatusInternalServerError, "Transaction could not be routed to a queue"
+	case errors.Is(err, rabbitmq.ErrNacked), errors.Is(err, rabbitmq.ErrChannelClosed),
+		errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable, "Message queue did not confirm the transaction"
+	default:
+		return http.StatusInternalServerError, "Failed to publish transaction"
+	}
+}
+
+// GetTransactionStatus returns where a published transaction is in its processing
+func (h *PublisherHandler) GetTransactionStatus(c *gin.Context) {
+	if h.statuses == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":  "Transaction status tracking is currently unavailable",
+			"status": "service_unavailable",
+		})
+		return
+	}
+
+	status, err := h.statuses.GetStatus(c.Request.Context(), c.Param("transaction_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// recordStatus runs a status update if tracking is enabled. Failures are only logged:
+// the transaction is still published and the consumer records its later statuses.
+func (h *PublisherHandler) recordStatus(transactionID string, update func(service.StatusService) error) {
+	if h.statuses == nil {
+		return
+	}
+	if err := update(h.statuses); err != nil {
+		log.Printf("Failed to record status of transaction %s: %v", transactionID, err)
+	}
+}
+
+// markPublishFailed records a failed publish as rejected. It uses a context of its own, as
+// the publish may have failed because the request context expired or was canceled.
+func (h *PublisherHandler) markPublishFailed(transactionID string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), statusUpdateTimeout)
+	defer cancel()
+
+	h.recordStatus(transactionID, func(statuses service.StatusService) error {
+		return statuses.MarkRejected(ctx, transactionID, "failed to publish: "+err.Error())
+	})
+}
+
+// HealthCheck reports whether the publisher service is alive. It answers 200 even while the
+// broker is unreachable, reporting it as degraded, so the instance stays in rotation and the
+// status endpoints, which only need the database, keep working.
 func (h *PublisherHandler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":    "ok",
+	h.brokerStatus(c, http.StatusOK)
+}
+
+// ReadinessCheck reports whether transactions can be published: 503 until the broker is connected
+func (h *PublisherHandler) ReadinessCheck(c *gin.Context) {
+	h.brokerStatus(c, http.StatusServiceUnavailable)
+}
+
+// brokerStatus responds with the broker connection state, using degradedCode when it is not connected
+func (h *PublisherHandler) brokerStatus(c *gin.Context, degradedCode int) {
+	broker := rabbitmq.StateConnecting
+	if publisher := h.publisher.Load(); publisher != nil {
+		broker = publisher.State()
+	}
+
+	status, code := "ok", http.StatusOK
+	if broker != rabbitmq.StateConnected {
+		status, code = "degraded", degradedCode
+	}
+
+	c.JSON(code, gin.H{
+		"status":    status,
+		"rabbitmq":  broker.String(),
 		"service":   "transaction-publisher",
 		"timestamp": time.Now().UTC(),
 		"version":   "1.0.0",
@@ -101,4 +325,4 @@ func (h *PublisherHandler) GetMetrics(c *gin.Context) {
 		"uptime":    time.Since(time.Unix(metrics.LastRequestTime, 0)).String(),
 		"timestamp": time.Now().UTC(),
 	})
-}
\ No newline at end of file
+}
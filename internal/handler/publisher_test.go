@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"register-payment/internal/dto"
+	"register-payment/internal/entity"
+	"register-payment/pkg/rabbitmq"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDecodeBatch(t *testing.T) {
+	items := []json.RawMessage{
+		json.RawMessage(`{"transaction_id":"TX-1","value":"10.00","type":"in","external_company_id":"COMP-1"}`),
+		json.RawMessage(`{"transaction_id":"TX-2","value":"10.00","type":"both","external_company_id":"COMP-1"}`),
+		json.RawMessage(`"TX-3"`),
+		json.RawMessage(`{"transaction_id":"TX-1","value":"5.00","type":"out","external_company_id":"COMP-1"}`),
+		json.RawMessage(`{"transaction_id":"TX-4","value":"1000","currency":"JPY","type":"out","external_company_id":"COMP-2"}`),
+	}
+
+	reqs, results := decodeBatch(dto.NewValidator(), items)
+
+	wantQueued := []bool{true, false, false, false, true}
+	for i, want := range wantQueued {
+		queued := results[i].Status == entity.StatusQueued
+		if queued != want || (reqs[i] != nil) != want {
+			t.Errorf("item %d = %+v, want queued %v", i, results[i], want)
+		}
+		if results[i].Index != i {
+			t.Errorf("item %d has index %d", i, results[i].Index)
+		}
+	}
+	if results[1].TransactionID != "TX-2" || results[1].Error == "" {
+		t.Errorf("invalid item result = %+v, want its transaction_id and error", results[1])
+	}
+	if !strings.Contains(results[3].Error, "index 0") {
+		t.Errorf("duplicate error = %q, want it to name index 0", results[3].Error)
+	}
+}
+
+func TestPublishTransactionsRequestErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/batch", NewPublisherHandler(nil, nil).PublishTransactions)
+
+	valid := `[{"transaction_id":"TX-1","value":"10.00","type":"in","external_company_id":"COMP-1"}]`
+	tooMany := "[" + strings.Repeat(`{},`, dto.MaxBatchSize) + "{}]"
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"not an array", `{"transaction_id":"TX-1"}`, http.StatusBadRequest},
+		{"empty", `[]`, http.StatusBadRequest},
+		{"too many", tooMany, http.StatusBadRequest},
+		{"no publisher", valid, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serve(router, http.MethodPost, "/batch", tt.body); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestPublishFailure(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{&rabbitmq.ReturnedError{ReplyCode: 312, ReplyText: "NO_ROUTE"}, http.StatusInternalServerError},
+		{rabbitmq.ErrNacked, http.StatusServiceUnavailable},
+		{fmt.Errorf("waiting for publisher confirm: %w", context.DeadlineExceeded), http.StatusServiceUnavailable},
+		{errors.New("failed to marshal message"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if status, _ := publishFailure(tt.err); status != tt.want {
+			t.Errorf("publishFailure(%v) = %d, want %d", tt.err, status, tt.want)
+		}
+	}
+}
+
+func TestPublisherHealthCheckReflectsBroker(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewPublisherHandler(nil, nil)
+	router := gin.New()
+	router.GET("/health", h.HealthCheck)
+	router.GET("/ready", h.ReadinessCheck)
+
+	// Liveness stays up while the broker is unreachable; readiness does not
+	rec := serve(router, http.MethodGet, "/health", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"degraded"`) ||
+		!strings.Contains(rec.Body.String(), `"rabbitmq":"connecting"`) {
+		t.Errorf("health before connecting = %d %s, want 200 degraded connecting", rec.Code, rec.Body)
+	}
+	rec = serve(router, http.MethodGet, "/ready", "")
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"rabbitmq":"connecting"`) {
+		t.Errorf("readiness before connecting = %d %s, want 503 connecting", rec.Code, rec.Body)
+	}
+
+	// A connection that was never set up is not connected
+	h.SetPublisher(rabbitmq.NewPublisher(&rabbitmq.Connection{}, "transactions"))
+	rec = serve(router, http.MethodGet, "/ready", "")
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"rabbitmq":"connecting"`) {
+		t.Errorf("readiness with an unset connection = %d %s, want 503 connecting", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"register-payment/internal/dto"
+	"register-payment/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReportHandler serves aggregated transaction reports over HTTP
+type ReportHandler struct {
+	reportService service.ReportService
+}
+
+func NewReportHandler(reportService service.ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+// RegisterRoutes adds the report routes to the group
+func (h *ReportHandler) RegisterRoutes(api *gin.RouterGroup) {
+	api.GET("/reports/totals", h.GetTotals)
+}
+
+// GetTotals returns count, sum in, sum out and net per company, currency and period,
+// e.g. /reports/totals?bucket=month&time_zone=America/Sao_Paulo&created_from=2025-01-01
+func (h *ReportHandler) GetTotals(c *gin.Context) {
+	var query dto.ReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+
+	report, err := h.reportService.GetTotals(c.Request.Context(), &query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondJSON(c, http.StatusOK, report)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"register-payment/internal/entity"
+	"register-payment/internal/repository"
+	"register-payment/internal/service"
+	"register-payment/pkg/money"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeReportRepository returns fixed totals and remembers the last query
+type fakeReportRepository struct {
+	totals []*entity.PeriodTotal
+	query  repository.ReportQuery
+}
+
+func (r *fakeReportRepository) Totals(ctx context.Context, query repository.ReportQuery) ([]*entity.PeriodTotal, error) {
+	r.query = query
+	return r.totals, nil
+}
+
+func TestReportHandlerTotals(t *testing.T) {
+	repo := &fakeReportRepository{totals: []*entity.PeriodTotal{{
+		ExternalCompanyID: "COMP-1",
+		Currency:          money.BRL,
+		PeriodStart:       time.Date(2025, 8, 1, 3, 0, 0, 0, time.UTC),
+		Count:             3,
+		TotalIn:           money.NewMoneyFromCents(20000),
+		TotalOut:          money.NewMoneyFromCents(5050),
+	}}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewReportHandler(service.NewReportService(repo, service.Timeouts{})).RegisterRoutes(router.Group("/api/v1"))
+
+	rec := serve(router, http.MethodGet, "/api/v1/reports/totals?bucket=month&time_zone=America/Sao_Paulo&created_from=2025-08-01&external_company_id=COMP-1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("totals = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var report struct {
+		Bucket   string `json:"bucket"`
+		TimeZone string `json:"time_zone"`
+		Totals   []struct {
+			Net string `json:"net"`
+		} `json:"totals"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body.String(), err)
+	}
+	if report.Bucket != "month" || report.TimeZone != "America/Sao_Paulo" || len(report.Totals) != 1 || report.Totals[0].Net != "149.50" {
+		t.Errorf("unexpected report: %s", rec.Body.String())
+	}
+
+	// Dates are local midnight in the report's time zone
+	if want := time.Date(2025, 8, 1, 3, 0, 0, 0, time.UTC); !repo.query.CreatedFrom.Equal(want) || repo.query.ExternalCompanyID != "COMP-1" {
+		t.Errorf("query = %+v, want created_from %v for COMP-1", repo.query, want)
+	}
+
+	for _, target := range []string{
+		"/api/v1/reports/totals?time_zone=Mars/Olympus",
+		"/api/v1/reports/totals?bucket=fortnight",
+		"/api/v1/reports/totals?bucket=1500ms",
+	} {
+		if rec := serve(router, http.MethodGet, target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+
+	serve(router, http.MethodGet, "/api/v1/reports/totals?bucket=15m", "")
+	if repo.query.Interval != 15*time.Minute || repo.query.Unit != "" || repo.query.Location != time.UTC {
+		t.Errorf("15m bucket query = %+v", repo.query)
+	}
+}
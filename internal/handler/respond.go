@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+	"register-payment/pkg/money"
+
+	"github.com/gin-gonic/gin"
+)
+
+// moneyFormatParam is the query parameter that selects how money values are written,
+// e.g. ?money_format=cents
+const moneyFormatParam = "money_format"
+
+// respondJSON writes obj as JSON with its money values in the format the request asked for.
+// Money is written as decimal strings when the request does not pick a format.
+func respondJSON(c *gin.Context, status int, obj interface{}) {
+	name := c.Query(moneyFormatParam)
+	if name == "" {
+		c.JSON(status, obj)
+		return
+	}
+
+	format, err := money.ParseJSONFormat(name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+	c.JSON(status, money.ApplyJSONFormat(obj, format))
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"register-payment/internal/dto"
+	"register-payment/internal/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// TransactionHandler serves registered transactions over HTTP
+type TransactionHandler struct {
+	transactionService service.TransactionService
+	validate           *validator.Validate
+}
+
+func NewTransactionHandler(transactionService service.TransactionService) *TransactionHandler {
+	return &TransactionHandler{
+		transactionService: transactionService,
+		validate:           dto.NewValidator(),
+	}
+}
+
+// RegisterRoutes adds the transaction routes to the group
+func (h *TransactionHandler) RegisterRoutes(api *gin.RouterGroup) {
+	transactions := api.Group("/transactions")
+	{
+		transactions.GET("", h.ListTransactions)
+		transactions.GET("/:id", h.GetTransaction)
+		transactions.PUT("/:id", h.UpdateTransaction)
+		transactions.DELETE("/:id", h.DeleteTransaction)
+		transactions.GET("/by-transaction-id/:transaction_id", h.GetTransactionByID)
+	}
+	api.GET("/companies/:external_company_id/transactions", h.GetTransactionsByCompany)
+	api.GET("/companies/:external_company_id/balance", h.GetCompanyBalance)
+	api.GET("/balances/drift", h.CheckBalances)
+}
+
+// ListTransactions returns a page of transactions matching the query string filters, newest first.
+// Pass the returned next_cursor as cursor to read the following page.
+func (h *TransactionHandler) ListTransactions(c *gin.Context) {
+	var query dto.TransactionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+
+	h.searchTransactions(c, &query)
+}
+
+// GetTransaction returns a transaction by its internal ID
+func (h *TransactionHandler) GetTransaction(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	transaction, err := h.transactionService.GetTransaction(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondJSON(c, http.StatusOK, transaction)
+}
+
+// GetTransactionByID returns a transaction by the transaction_id given by its sender
+func (h *TransactionHandler) GetTransactionByID(c *gin.Context) {
+	transaction, err := h.transactionService.GetTransactionByID(c.Request.Context(), c.Param("transaction_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondJSON(c, http.StatusOK, transaction)
+}
+
+// GetTransactionsByCompany returns a page of a company's transactions, accepting the same filters as ListTransactions
+func (h *TransactionHandler) GetTransactionsByCompany(c *gin.Context) {
+	var query dto.TransactionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+		return
+	}
+	query.ExternalCompanyID = c.Param("external_company_id")
+
+	h.searchTransactions(c, &query)
+}
+
+// GetCompanyBalance returns the company's balance in ?currency= (default BRL), as of now or as
+// it stood at ?at= (RFC 3339). The balance is the company's customer ledger account, so incoming
+// payments count net of fees.
+func (h *TransactionHandler) GetCompanyBalance(c *gin.Context) {
+	companyID, currency := c.Param("external_company_id"), c.Query("currency")
+
+	var balance *dto.BalanceResponse
+	var err error
+	if value := c.Query("at"); value != "" {
+		at, parseErr := time.Parse(time.RFC3339, value)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": "at: " + parseErr.Error()})
+			return
+		}
+		balance, err = h.transactionService.GetBalanceAt(c.Request.Context(), companyID, currency, at)
+	} else {
+		balance, err = h.transactionService.GetBalance(c.Request.Context(), companyID, currency)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondJSON(c, http.StatusOK, balance)
+}
+
+// CheckBalances recomputes every stored balance from the customer ledger accounts and lists the
+// ones that drifted. Both sides are net of fees, so this does not compare against transaction totals.
+func (h *TransactionHandler) CheckBalances(c *gin.Context) {
+	drifts, err := h.transactionService.CheckBalances(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondJSON(c, http.StatusOK, gin.H{"drifts": drifts, "total": len(drifts)})
+}
+
+func (h *TransactionHandler) searchTransactions(c *gin.Context, query *dto.TransactionQuery) {
+	page, err := h.transactionService.SearchTransactions(c.Request.Context(), query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondJSON(c, http.StatusOK, page)
+}
+
+// UpdateTransaction replaces a transaction, validating the body like a new transaction
+func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.TransactionRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+
+	transaction, err := h.transactionService.UpdateTransaction(c.Request.Context(), id, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondJSON(c, http.StatusOK, transaction)
+}
+
+// DeleteTransaction removes a transaction; its journal entries are reversed, not deleted
+func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.transactionService.DeleteTransaction(c.Request.Context(), id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// pathID reads the numeric :id parameter, answering 400 if it is not one
+func pathID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID", "details": c.Param("id")})
+		return 0, false
+	}
+	return id, true
+}
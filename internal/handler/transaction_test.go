@@ -0,0 +1,406 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"register-payment/internal/entity"
+	"register-payment/internal/repository"
+	"register-payment/internal/service"
+	"register-payment/pkg/money"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeRepository is an in-memory TransactionRepository
+type fakeRepository struct {
+	transactions map[int]*entity.Transaction
+	nextID       int
+	err          error
+}
+
+func newFakeRepository(transactions ...*entity.Transaction) *fakeRepository {
+	repo := &fakeRepository{transactions: map[int]*entity.Transaction{}, nextID: 1}
+	for _, t := range transactions {
+		repo.Create(context.Background(), t, nil)
+	}
+	return repo
+}
+
+func (r *fakeRepository) Create(ctx context.Context, transaction *entity.Transaction, entry *entity.JournalEntry) error {
+	if r.err != nil {
+		return r.err
+	}
+	for _, existing := range r.transactions {
+		if existing.TransactionID == transaction.TransactionID {
+			return &repository.DuplicateError{TransactionID: transaction.TransactionID, Existing: existing}
+		}
+	}
+	transaction.ID = r.nextID
+	transaction.CreatedAt = time.Now()
+	transaction.UpdatedAt = transaction.CreatedAt
+	r.nextID++
+	r.transactions[transaction.ID] = transaction
+	return nil
+}
+
+func (r *fakeRepository) CreateBatch(ctx context.Context, transactions []*entity.Transaction, entries []*entity.JournalEntry) ([]error, error) {
+	results := make([]error, len(transactions))
+	for i, transaction := range transactions {
+		err := r.Create(ctx, transaction, entries[i])
+		if errors.Is(err, repository.ErrDuplicate) {
+			results[i] = err
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (r *fakeRepository) GetByID(ctx context.Context, id int) (*entity.Transaction, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	transaction, ok := r.transactions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *transaction
+	return &copied, nil
+}
+
+func (r *fakeRepository) GetByTransactionID(ctx context.Context, transactionID string) (*entity.Transaction, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, transaction := range r.transactions {
+		if transaction.TransactionID == transactionID {
+			return transaction, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeRepository) GetByExternalCompanyID(ctx context.Context, externalCompanyID string) ([]*entity.Transaction, error) {
+	var transactions []*entity.Transaction
+	for _, transaction := range r.sorted() {
+		if transaction.ExternalCompanyID == externalCompanyID {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions, r.err
+}
+
+func (r *fakeRepository) List(ctx context.Context, limit, offset int) ([]*entity.Transaction, error) {
+	transactions := r.sorted()
+	if offset > len(transactions) {
+		offset = len(transactions)
+	}
+	transactions = transactions[offset:]
+	if limit < len(transactions) {
+		transactions = transactions[:limit]
+	}
+	return transactions, r.err
+}
+
+func (r *fakeRepository) Search(ctx context.Context, query repository.TransactionQuery) ([]*entity.Transaction, error) {
+	var transactions []*entity.Transaction
+	for _, transaction := range r.sorted() {
+		if !matches(query.TransactionFilter, transaction) {
+			continue
+		}
+		if after := query.After; after != nil && !transaction.CreatedAt.Before(after.CreatedAt) &&
+			!(transaction.CreatedAt.Equal(after.CreatedAt) && transaction.ID < after.ID) {
+			continue
+		}
+		if len(transactions) == query.Limit {
+			break
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, r.err
+}
+
+func (r *fakeRepository) Count(ctx context.Context, filter repository.TransactionFilter) (int, error) {
+	count := 0
+	for _, transaction := range r.transactions {
+		if matches(filter, transaction) {
+			count++
+		}
+	}
+	return count, r.err
+}
+
+func (r *fakeRepository) Stream(ctx context.Context, filter repository.TransactionFilter, fn func(*entity.Transaction) error) error {
+	if r.err != nil {
+		return r.err
+	}
+	transactions := r.sorted()
+	for i := len(transactions) - 1; i >= 0; i-- {
+		if !matches(filter, transactions[i]) {
+			continue
+		}
+		if err := fn(transactions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matches(f repository.TransactionFilter, t *entity.Transaction) bool {
+	return (f.ExternalCompanyID == "" || t.ExternalCompanyID == f.ExternalCompanyID) &&
+		(f.Type == "" || t.Type == f.Type) &&
+		(f.Currency == "" || t.Value.Currency() == f.Currency) &&
+		(f.MinValue == nil || !t.Value.LessThan(*f.MinValue) && t.Value.Currency() == f.MinValue.Currency()) &&
+		(f.MaxValue == nil || !t.Value.GreaterThan(*f.MaxValue) && t.Value.Currency() == f.MaxValue.Currency()) &&
+		(f.CreatedFrom.IsZero() || !t.CreatedAt.Before(f.CreatedFrom)) &&
+		(f.CreatedTo.IsZero() || t.CreatedAt.Before(f.CreatedTo)) &&
+		(f.Description == "" || strings.Contains(strings.ToLower(t.Description), strings.ToLower(f.Description)))
+}
+
+func (r *fakeRepository) Update(ctx context.Context, transaction *entity.Transaction, entry *entity.JournalEntry) error {
+	if r.err != nil {
+		return r.err
+	}
+	for id, existing := range r.transactions {
+		if id != transaction.ID && existing.TransactionID == transaction.TransactionID {
+			return &repository.DuplicateError{TransactionID: transaction.TransactionID}
+		}
+	}
+	transaction.UpdatedAt = time.Now()
+	r.transactions[transaction.ID] = transaction
+	return nil
+}
+
+func (r *fakeRepository) Delete(ctx context.Context, id int) error {
+	if r.err != nil {
+		return r.err
+	}
+	delete(r.transactions, id)
+	return nil
+}
+
+func (r *fakeRepository) sorted() []*entity.Transaction {
+	transactions := make([]*entity.Transaction, 0, len(r.transactions))
+	for _, transaction := range r.transactions {
+		transactions = append(transactions, transaction)
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		if !transactions[i].CreatedAt.Equal(transactions[j].CreatedAt) {
+			return transactions[i].CreatedAt.After(transactions[j].CreatedAt)
+		}
+		return transactions[i].ID > transactions[j].ID
+	})
+	return transactions
+}
+
+func newTestRouter(repo *fakeRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	transactionService := service.NewTransactionService(repo, nil, nil, service.PostingRules{}, service.Timeouts{})
+
+	router := gin.New()
+	NewTransactionHandler(transactionService).RegisterRoutes(router.Group("/api/v1"))
+	return router
+}
+
+func seededRepository() *fakeRepository {
+	return newFakeRepository(
+		&entity.Transaction{TransactionID: "TXN-1", Value: money.NewMoneyFromCents(15075), Type: "in", ExternalCompanyID: "COMP-1"},
+		&entity.Transaction{TransactionID: "TXN-2", Value: money.NewMoneyFromCents(5000), Type: "out", ExternalCompanyID: "COMP-1"},
+		&entity.Transaction{TransactionID: "TXN-3", Value: money.NewMoneyFromCents(100), Type: "in", ExternalCompanyID: "COMP-2"},
+	)
+}
+
+func serve(router *gin.Engine, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTransactionHandlerStatusCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		expected int
+	}{
+		{"get by id", http.MethodGet, "/api/v1/transactions/1", "", http.StatusOK},
+		{"get missing id", http.MethodGet, "/api/v1/transactions/99", "", http.StatusNotFound},
+		{"get invalid id", http.MethodGet, "/api/v1/transactions/abc", "", http.StatusBadRequest},
+		{"get by transaction_id", http.MethodGet, "/api/v1/transactions/by-transaction-id/TXN-2", "", http.StatusOK},
+		{"get missing transaction_id", http.MethodGet, "/api/v1/transactions/by-transaction-id/TXN-9", "", http.StatusNotFound},
+		{"list", http.MethodGet, "/api/v1/transactions?limit=2", "", http.StatusOK},
+		{"list invalid limit", http.MethodGet, "/api/v1/transactions?limit=ten", "", http.StatusBadRequest},
+		{"list filtered", http.MethodGet, "/api/v1/transactions?type=in&min_value=100.00&description=x", "", http.StatusOK},
+		{"list invalid type", http.MethodGet, "/api/v1/transactions?type=sideways", "", http.StatusBadRequest},
+		{"list invalid value", http.MethodGet, "/api/v1/transactions?min_value=1.234", "", http.StatusBadRequest},
+		{"list invalid date", http.MethodGet, "/api/v1/transactions?created_from=yesterday", "", http.StatusBadRequest},
+		{"list invalid cursor", http.MethodGet, "/api/v1/transactions?cursor=!!", "", http.StatusBadRequest},
+		{"by company", http.MethodGet, "/api/v1/companies/COMP-1/transactions", "", http.StatusOK},
+		{"balance invalid at", http.MethodGet, "/api/v1/companies/COMP-1/balance?at=yesterday", "", http.StatusBadRequest},
+		{"balance invalid currency", http.MethodGet, "/api/v1/companies/COMP-1/balance?currency=XXX", "", http.StatusBadRequest},
+		{"balance at invalid currency", http.MethodGet, "/api/v1/companies/COMP-1/balance?currency=XXX&at=2025-08-01T00:00:00Z", "", http.StatusBadRequest},
+		{"update", http.MethodPut, "/api/v1/transactions/1",
+			`{"transaction_id":"TXN-1","value":"200.00","type":"in","external_company_id":"COMP-1"}`, http.StatusOK},
+		{"update to existing transaction_id", http.MethodPut, "/api/v1/transactions/1",
+			`{"transaction_id":"TXN-2","value":"200.00","type":"in","external_company_id":"COMP-1"}`, http.StatusConflict},
+		{"update missing", http.MethodPut, "/api/v1/transactions/99",
+			`{"transaction_id":"TXN-9","value":"200.00","type":"in","external_company_id":"COMP-1"}`, http.StatusNotFound},
+		{"update invalid value", http.MethodPut, "/api/v1/transactions/1",
+			`{"transaction_id":"TXN-1","value":"-1.00","type":"in","external_company_id":"COMP-1"}`, http.StatusBadRequest},
+		{"update malformed", http.MethodPut, "/api/v1/transactions/1", `{"value":`, http.StatusBadRequest},
+		{"delete", http.MethodDelete, "/api/v1/transactions/3", "", http.StatusNoContent},
+		{"delete missing", http.MethodDelete, "/api/v1/transactions/99", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(newTestRouter(seededRepository()), tt.method, tt.target, tt.body)
+			if rec.Code != tt.expected {
+				t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.target, rec.Code, tt.expected, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestTransactionHandlerResponses(t *testing.T) {
+	repo := seededRepository()
+	router := newTestRouter(repo)
+
+	rec := serve(router, http.MethodGet, "/api/v1/companies/COMP-1/transactions", "")
+	var list struct {
+		Transactions []struct {
+			TransactionID string `json:"transaction_id"`
+			Value         string `json:"value"`
+		} `json:"transactions"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body.String(), err)
+	}
+	if len(list.Transactions) != 2 || list.Transactions[0].TransactionID != "TXN-2" || list.Transactions[1].Value != "150.75" {
+		t.Errorf("unexpected company transactions: %s", rec.Body.String())
+	}
+
+	rec = serve(router, http.MethodGet, "/api/v1/companies/COMP-9/transactions", "")
+	if body := strings.TrimSpace(rec.Body.String()); body != `{"transactions":[],"total":0}` {
+		t.Errorf("empty company = %s, want an empty list", body)
+	}
+
+	rec = serve(router, http.MethodPut, "/api/v1/transactions/2",
+		`{"transaction_id":"TXN-2","value":"75.50","type":"out","external_company_id":"COMP-1"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update = %d: %s", rec.Code, rec.Body.String())
+	}
+	if stored := repo.transactions[2].Value; stored.Cents() != 7550 {
+		t.Errorf("stored value after update = %s, want 75.50", stored)
+	}
+
+	serve(router, http.MethodDelete, "/api/v1/transactions/2", "")
+	if _, ok := repo.transactions[2]; ok {
+		t.Error("transaction still stored after delete")
+	}
+}
+
+func TestTransactionHandlerTransientError(t *testing.T) {
+	repo := seededRepository()
+	repo.err = context.DeadlineExceeded
+
+	rec := serve(newTestRouter(repo), http.MethodGet, "/api/v1/transactions/1", "")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("timed out query = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestTransactionHandlerPagination(t *testing.T) {
+	router := newTestRouter(seededRepository())
+
+	type page struct {
+		Transactions []struct {
+			TransactionID string `json:"transaction_id"`
+		} `json:"transactions"`
+		NextCursor string `json:"next_cursor"`
+		Total      int    `json:"total"`
+	}
+
+	var seen []string
+	target := "/api/v1/transactions?limit=2"
+	for pages := 0; target != ""; pages++ {
+		if pages == 3 {
+			t.Fatal("pagination did not end")
+		}
+		rec := serve(router, http.MethodGet, target, "")
+		var p page
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatalf("decoding %s: %v", rec.Body.String(), err)
+		}
+		if p.Total != 3 {
+			t.Errorf("total = %d, want 3", p.Total)
+		}
+		for _, transaction := range p.Transactions {
+			seen = append(seen, transaction.TransactionID)
+		}
+		target = ""
+		if p.NextCursor != "" {
+			target = "/api/v1/transactions?limit=2&cursor=" + p.NextCursor
+		}
+	}
+
+	if strings.Join(seen, ",") != "TXN-3,TXN-2,TXN-1" {
+		t.Errorf("paged through %v, want TXN-3,TXN-2,TXN-1", seen)
+	}
+}
+
+func TestTransactionHandlerMoneyFormat(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+		code     int
+	}{
+		{"", `"150.75"`, http.StatusOK},
+		{"?money_format=string", `"150.75"`, http.StatusOK},
+		{"?money_format=number", `150.75`, http.StatusOK},
+		{"?money_format=cents", `{"amount_cents":15075,"currency":"BRL"}`, http.StatusOK},
+		{"?money_format=float", "", http.StatusBadRequest},
+	}
+
+	router := newTestRouter(seededRepository())
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, "/api/v1/transactions/1"+tt.query, "")
+			if rec.Code != tt.code {
+				t.Fatalf("GET %s = %d, want %d: %s", tt.query, rec.Code, tt.code, rec.Body.String())
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+
+			var transaction struct {
+				Value json.RawMessage `json:"value"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &transaction); err != nil {
+				t.Fatalf("decoding %s: %v", rec.Body.String(), err)
+			}
+			if string(transaction.Value) != tt.expected {
+				t.Errorf("value = %s, want %s", transaction.Value, tt.expected)
+			}
+		})
+	}
+
+	// Other requests keep the default format
+	rec := serve(router, http.MethodGet, "/api/v1/companies/COMP-2/transactions", "")
+	if !strings.Contains(rec.Body.String(), `"value":"1.00"`) {
+		t.Errorf("company transactions after a cents request = %s, want string values", rec.Body.String())
+	}
+}
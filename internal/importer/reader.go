@@ -0,0 +1,175 @@
+// Package importer reads transaction requests from CSV or NDJSON files for bulk imports
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"register-payment/internal/dto"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Formats accepted by NewReader
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// csvColumns are the CSV header names mapped to request fields. Other columns, such as
+// the id and created_at of an export, are ignored.
+var csvColumns = []string{"transaction_id", "value", "currency", "type", "external_company_id", "description"}
+
+// Row is one transaction read from the input. Err is set when the row cannot be imported,
+// in which case Request may be partially filled or nil.
+type Row struct {
+	Line    int
+	Request *dto.TransactionRequest
+	Err     error
+}
+
+// Reader reads rows one at a time, validating each with the rules of the publisher API.
+// A transaction_id repeated within the input is reported on its later rows.
+type Reader struct {
+	next     func() (*Row, error)
+	validate *validator.Validate
+	seen     map[string]int
+}
+
+// FormatFromPath guesses the format from a file extension, defaulting to CSV
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	default:
+		return FormatCSV
+	}
+}
+
+// NewReader reads r in the given format. CSV input must start with a header row
+// naming at least the transaction_id, value, type and external_company_id columns.
+func NewReader(r io.Reader, format string) (*Reader, error) {
+	reader := &Reader{validate: dto.NewValidator(), seen: map[string]int{}}
+
+	switch format {
+	case FormatCSV:
+		next, err := csvRows(r)
+		if err != nil {
+			return nil, err
+		}
+		reader.next = next
+	case FormatNDJSON:
+		reader.next = ndjsonRows(r)
+	default:
+		return nil, fmt.Errorf("unknown import format %q, want csv or ndjson", format)
+	}
+
+	return reader, nil
+}
+
+// Next returns the next row, or io.EOF when the input is exhausted. Invalid rows are
+// returned with Err set; only input that cannot be read at all returns an error.
+func (r *Reader) Next() (*Row, error) {
+	row, err := r.next()
+	if err != nil || row.Err != nil {
+		return row, err
+	}
+
+	if err := r.validate.Struct(row.Request); err != nil {
+		row.Err = err
+		return row, nil
+	}
+
+	id := row.Request.TransactionID
+	if line, ok := r.seen[id]; ok {
+		row.Err = fmt.Errorf("transaction_id %s already appears on line %d", id, line)
+		return row, nil
+	}
+	r.seen[id] = row.Line
+
+	return row, nil
+}
+
+func ndjsonRows(r io.Reader) func() (*Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+
+	return func() (*Row, error) {
+		for scanner.Scan() {
+			line++
+			data := bytes.TrimSpace(scanner.Bytes())
+			if len(data) == 0 {
+				continue
+			}
+			row := &Row{Line: line, Request: &dto.TransactionRequest{}}
+			row.Err = json.Unmarshal(data, row.Request)
+			return row, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+}
+
+func csvRows(r io.Reader) (func() (*Row, error), error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty CSV input, want a header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Map each known column to its position in the file
+	positions := map[string]int{}
+	for i, name := range header {
+		positions[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"transaction_id", "value", "type", "external_company_id"} {
+		if _, ok := positions[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing the %s column", required)
+		}
+	}
+
+	return func() (*Row, error) {
+		record, err := reader.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return &Row{Line: parseErr.StartLine, Err: err}, nil
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		// Decode through JSON so values follow exactly the rules of API requests
+		fields := map[string]string{}
+		for _, name := range csvColumns {
+			if i, ok := positions[name]; ok && i < len(record) {
+				fields[name] = strings.TrimSpace(record[i])
+			}
+		}
+		if fields["currency"] == "" {
+			delete(fields, "currency")
+		}
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		row := &Row{Line: line, Request: &dto.TransactionRequest{}}
+		row.Err = json.Unmarshal(data, row.Request)
+		return row, nil
+	}, nil
+}
@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func readAll(t *testing.T, input, format string) []*Row {
+	t.Helper()
+
+	reader, err := NewReader(strings.NewReader(input), format)
+	if err != nil {
+		t.Fatalf("NewReader error: %v", err)
+	}
+
+	var rows []*Row
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("Next error: %v", err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	input := "id,transaction_id,value,currency,type,external_company_id,description\n" +
+		"1,TX-1,150.75,,in,COMP-1,\"Sale, store 2\"\n" +
+		"2,TX-2,1000,JPY,out,COMP-1,\n" +
+		"3,TX-3,-5,BRL,in,COMP-2,\n" +
+		"4,TX-4,10,BRL,both,COMP-2,\n" +
+		"5,TX-1,10,BRL,in,COMP-2,\n"
+
+	rows := readAll(t, input, FormatCSV)
+	if len(rows) != 5 {
+		t.Fatalf("read %d rows, want 5", len(rows))
+	}
+
+	first := rows[0]
+	if first.Err != nil {
+		t.Fatalf("row 1 error: %v", first.Err)
+	}
+	if first.Line != 2 || first.Request.TransactionID != "TX-1" || first.Request.Value.Cents() != 15075 ||
+		first.Request.Description != "Sale, store 2" {
+		t.Errorf("row 1 = line %d %+v", first.Line, first.Request)
+	}
+	if rows[1].Err != nil || rows[1].Request.Value.Cents() != 1000 {
+		t.Errorf("row 2 = %+v, error %v, want JPY 1000", rows[1].Request, rows[1].Err)
+	}
+
+	for _, row := range rows[2:] {
+		if row.Err == nil {
+			t.Errorf("line %d was accepted, want an error", row.Line)
+		}
+	}
+	if !strings.Contains(rows[4].Err.Error(), "line 2") {
+		t.Errorf("duplicate error = %v, want it to name line 2", rows[4].Err)
+	}
+}
+
+func TestReadCSVRequiresHeader(t *testing.T) {
+	if _, err := NewReader(strings.NewReader("transaction_id,value,type\nTX-1,10,in\n"), FormatCSV); err == nil {
+		t.Error("NewReader accepted a header without external_company_id")
+	}
+	if _, err := NewReader(strings.NewReader(""), FormatCSV); err == nil {
+		t.Error("NewReader accepted an empty file")
+	}
+}
+
+func TestReadNDJSON(t *testing.T) {
+	input := `{"transaction_id":"TX-1","value":"150.75","type":"in","external_company_id":"COMP-1"}
+
+{"transaction_id":"TX-2","value":
+{"transaction_id":"TX-3","value":"10.00","type":"in"}
+`
+
+	rows := readAll(t, input, FormatNDJSON)
+	if len(rows) != 3 {
+		t.Fatalf("read %d rows, want 3", len(rows))
+	}
+	if rows[0].Err != nil || rows[0].Request.Value.Cents() != 15075 {
+		t.Errorf("row 1 = %+v, error %v", rows[0].Request, rows[0].Err)
+	}
+	if rows[1].Err == nil || rows[1].Line != 3 {
+		t.Errorf("malformed JSON on line %d, error %v, want an error on line 3", rows[1].Line, rows[1].Err)
+	}
+	if rows[2].Err == nil {
+		t.Error("row without external_company_id was accepted")
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"legacy.csv":    FormatCSV,
+		"legacy.NDJSON": FormatNDJSON,
+		"legacy.jsonl":  FormatNDJSON,
+		"legacy":        FormatCSV,
+	} {
+		if got := FormatFromPath(path); got != want {
+			t.Errorf("FormatFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"register-payment/internal/entity"
+	"register-payment/pkg/money"
+	"time"
+)
+
+type BalanceRepository interface {
+	Get(ctx context.Context, externalCompanyID string, currency money.Currency) (*entity.Balance, error)
+	GetAt(ctx context.Context, externalCompanyID string, currency money.Currency, at time.Time) (*entity.Balance, error)
+	ListByCompany(ctx context.Context, externalCompanyID string) ([]*entity.Balance, error)
+	FindDrift(ctx context.Context) ([]*entity.BalanceDrift, error)
+}
+
+type balanceRepository struct {
+	db *sql.DB
+}
+
+func NewBalanceRepository(db *sql.DB) BalanceRepository {
+	return &balanceRepository{db: db}
+}
+
+// Get returns the stored balance, or a zero balance if the company has no transactions in the currency
+func (r *balanceRepository) Get(ctx context.Context, externalCompanyID string, currency money.Currency) (*entity.Balance, error) {
+	query := `
+		SELECT external_company_id, currency, balance, updated_at
+		FROM balances
+		WHERE external_company_id = $1 AND currency = $2`
+
+	balance, err := scanBalance(r.db.QueryRowContext(ctx, query, externalCompanyID, currency))
+	if err == sql.ErrNoRows {
+		return &entity.Balance{
+			ExternalCompanyID: externalCompanyID,
+			Currency:          currency.Code(),
+			Balance:           money.NewMoneyFromMinorUnits(0, currency),
+		}, nil
+	}
+	return balance, err
+}
+
+// GetAt recomputes the balance from the customer account lines posted up to and including at
+func (r *balanceRepository) GetAt(ctx context.Context, externalCompanyID string, currency money.Currency, at time.Time) (*entity.Balance, error) {
+	query := `
+		SELECT COALESCE(-SUM(l.amount), 0)::BIGINT
+		FROM journal_lines l
+		JOIN journal_entries e ON e.id = l.journal_entry_id
+		JOIN accounts a ON a.id = l.account_id
+		WHERE a.code = $1 AND a.currency = $2 AND e.created_at <= $3`
+
+	var amount int64
+	if err := r.db.QueryRowContext(ctx, query, entity.CustomerAccountCode(externalCompanyID), currency, at).Scan(&amount); err != nil {
+		return nil, err
+	}
+
+	return &entity.Balance{
+		ExternalCompanyID: externalCompanyID,
+		Currency:          currency.Code(),
+		Balance:           money.NewMoneyFromMinorUnits(amount, currency),
+		UpdatedAt:         at,
+	}, nil
+}
+
+func (r *balanceRepository) ListByCompany(ctx context.Context, externalCompanyID string) ([]*entity.Balance, error) {
+	query := `
+		SELECT external_company_id, currency, balance, updated_at
+		FROM balances
+		WHERE external_company_id = $1
+		ORDER BY currency`
+
+	rows, err := r.db.QueryContext(ctx, query, externalCompanyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []*entity.Balance
+	for rows.Next() {
+		balance, err := scanBalance(rows)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+
+	return balances, rows.Err()
+}
+
+// FindDrift recomputes every balance from the customer account lines and returns those that
+// differ from the stored value, including balances missing on either side
+func (r *balanceRepository) FindDrift(ctx context.Context) ([]*entity.BalanceDrift, error) {
+	query := `
+		SELECT COALESCE(b.external_company_id, t.external_company_id),
+		       COALESCE(b.currency, t.currency),
+		       COALESCE(b.balance, 0),
+		       COALESCE(t.computed, 0)
+		FROM balances b
+		FULL OUTER JOIN (
+			SELECT a.external_company_id, a.currency, -SUM(l.amount)::BIGINT AS computed
+			FROM journal_lines l
+			JOIN accounts a ON a.id = l.account_id
+			WHERE a.external_company_id IS NOT NULL
+			GROUP BY a.external_company_id, a.currency
+		) t ON t.external_company_id = b.external_company_id AND t.currency = b.currency
+		WHERE COALESCE(b.balance, 0) <> COALESCE(t.computed, 0)
+		ORDER BY 1, 2`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var drifts []*entity.BalanceDrift
+	for rows.Next() {
+		drift := &entity.BalanceDrift{}
+		var stored, computed int64
+		if err := rows.Scan(&drift.ExternalCompanyID, &drift.Currency, &stored, &computed); err != nil {
+			return nil, err
+		}
+		drift.Stored = money.NewMoneyFromMinorUnits(stored, drift.Currency)
+		drift.Computed = money.NewMoneyFromMinorUnits(computed, drift.Currency)
+		drifts = append(drifts, drift)
+	}
+
+	return drifts, rows.Err()
+}
+
+func scanBalance(row rowScanner) (*entity.Balance, error) {
+	balance := &entity.Balance{}
+	err := row.Scan(
+		&balance.ExternalCompanyID,
+		&balance.Currency,
+		&balance.Balance,
+		&balance.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	balance.Balance = money.NewMoneyFromMinorUnits(balance.Balance.Cents(), balance.Currency)
+
+	return balance, nil
+}
+
+// applyBalanceDelta adds delta to the company's balance inside tx, creating the row on first use
+func applyBalanceDelta(ctx context.Context, tx *sql.Tx, externalCompanyID string, delta money.Money) error {
+	query := `
+		INSERT INTO balances (external_company_id, currency, balance, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (external_company_id, currency)
+		DO UPDATE SET balance = balances.balance + EXCLUDED.balance, updated_at = EXCLUDED.updated_at`
+
+	_, err := tx.ExecContext(ctx, query, externalCompanyID, delta.Currency(), delta)
+	return err
+}
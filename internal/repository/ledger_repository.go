@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"register-payment/internal/entity"
+	"register-payment/pkg/money"
+)
+
+type LedgerRepository interface {
+	GetEntriesByTransactionID(ctx context.Context, transactionID string) ([]*entity.JournalEntry, error)
+	GetAccountBalance(ctx context.Context, code string, currency money.Currency) (money.Money, error)
+}
+
+type ledgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (r *ledgerRepository) GetEntriesByTransactionID(ctx context.Context, transactionID string) ([]*entity.JournalEntry, error) {
+	query := `
+		SELECT e.id, e.transaction_id, e.kind, COALESCE(e.description, ''), e.created_at,
+		       l.id, a.code, a.type, COALESCE(a.external_company_id, ''), l.amount, l.currency
+		FROM journal_entries e
+		JOIN journal_lines l ON l.journal_entry_id = e.id
+		JOIN accounts a ON a.id = l.account_id
+		WHERE e.transaction_id = $1
+		ORDER BY e.id, l.id`
+
+	rows, err := r.db.QueryContext(ctx, query, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*entity.JournalEntry
+	for rows.Next() {
+		entry := &entity.JournalEntry{}
+		line := entity.JournalLine{}
+		var currency money.Currency
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TransactionID,
+			&entry.Kind,
+			&entry.Description,
+			&entry.CreatedAt,
+			&line.ID,
+			&line.AccountCode,
+			&line.AccountType,
+			&line.ExternalCompanyID,
+			&line.Amount,
+			&currency,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		line.JournalEntryID = entry.ID
+		line.Amount = money.NewMoneyFromMinorUnits(line.Amount.Cents(), currency)
+
+		if n := len(entries); n > 0 && entries[n-1].ID == entry.ID {
+			entries[n-1].Lines = append(entries[n-1].Lines, line)
+			continue
+		}
+		entry.Lines = []entity.JournalLine{line}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetAccountBalance returns the sum of all lines posted to an account: positive is a net debit
+func (r *ledgerRepository) GetAccountBalance(ctx context.Context, code string, currency money.Currency) (money.Money, error) {
+	query := `
+		SELECT COALESCE(SUM(l.amount), 0)::BIGINT
+		FROM journal_lines l
+		JOIN accounts a ON a.id = l.account_id
+		WHERE a.code = $1 AND a.currency = $2`
+
+	var amount int64
+	if err := r.db.QueryRowContext(ctx, query, code, currency).Scan(&amount); err != nil {
+		return money.Money{}, err
+	}
+
+	return money.NewMoneyFromMinorUnits(amount, currency), nil
+}
+
+// postJournalEntry validates and writes an entry with its lines inside tx, opening accounts
+// on first use. Lines on a company's customer account also move its stored balance.
+func postJournalEntry(ctx context.Context, tx *sql.Tx, entry *entity.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO journal_entries (transaction_id, kind, description, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id, created_at`
+
+	if err := tx.QueryRowContext(ctx, query, entry.TransactionID, entry.Kind, entry.Description).Scan(&entry.ID, &entry.CreatedAt); err != nil {
+		return err
+	}
+
+	lineQuery := `
+		INSERT INTO journal_lines (journal_entry_id, account_id, amount, currency)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	for i := range entry.Lines {
+		line := &entry.Lines[i]
+		accountID, err := ensureAccount(ctx, tx, line)
+		if err != nil {
+			return err
+		}
+
+		line.JournalEntryID = entry.ID
+		if err := tx.QueryRowContext(ctx, lineQuery, entry.ID, accountID, line.Amount, line.Amount.Currency()).Scan(&line.ID); err != nil {
+			return err
+		}
+
+		// The customer account is a liability: a credit (negative line) raises what the company is owed
+		if line.ExternalCompanyID != "" {
+			if err := applyBalanceDelta(ctx, tx, line.ExternalCompanyID, line.Amount.Negate()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// reverseJournalEntries posts reversals cancelling the net effect of every entry
+// already recorded for the transaction. It does nothing if that effect is already zero.
+func reverseJournalEntries(ctx context.Context, tx *sql.Tx, transactionID, description string) error {
+	query := `
+		SELECT a.code, a.type, COALESCE(a.external_company_id, ''), a.currency, SUM(l.amount)::BIGINT
+		FROM journal_entries e
+		JOIN journal_lines l ON l.journal_entry_id = e.id
+		JOIN accounts a ON a.id = l.account_id
+		WHERE e.transaction_id = $1
+		GROUP BY a.id, a.code, a.type, a.external_company_id, a.currency
+		HAVING SUM(l.amount) <> 0
+		ORDER BY a.id`
+
+	rows, err := tx.QueryContext(ctx, query, transactionID)
+	if err != nil {
+		return err
+	}
+
+	// A transaction whose currency was updated has lines in two currencies,
+	// so one balanced reversal is posted per currency
+	var reversals []*entity.JournalEntry
+	byCurrency := map[money.Currency]*entity.JournalEntry{}
+	for rows.Next() {
+		var line entity.JournalLine
+		var currency money.Currency
+		var net int64
+		if err := rows.Scan(&line.AccountCode, &line.AccountType, &line.ExternalCompanyID, &currency, &net); err != nil {
+			rows.Close()
+			return err
+		}
+		line.Amount = money.NewMoneyFromMinorUnits(-net, currency)
+
+		reversal, ok := byCurrency[currency]
+		if !ok {
+			reversal = &entity.JournalEntry{
+				TransactionID: transactionID,
+				Kind:          entity.JournalKindReversal,
+				Description:   description,
+			}
+			byCurrency[currency] = reversal
+			reversals = append(reversals, reversal)
+		}
+		reversal.Lines = append(reversal.Lines, line)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, reversal := range reversals {
+		if err := postJournalEntry(ctx, tx, reversal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureAccount returns the id of the line's account, creating it if needed
+func ensureAccount(ctx context.Context, tx *sql.Tx, line *entity.JournalLine) (int, error) {
+	query := `
+		INSERT INTO accounts (code, type, external_company_id, currency, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NOW())
+		ON CONFLICT (code, currency) DO UPDATE SET code = EXCLUDED.code
+		RETURNING id`
+
+	var id int
+	err := tx.QueryRowContext(ctx, query, line.AccountCode, line.AccountType, line.ExternalCompanyID, line.Amount.Currency()).Scan(&id)
+	return id, err
+}
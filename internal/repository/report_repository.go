@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"register-payment/internal/entity"
+	"register-payment/pkg/money"
+	"time"
+)
+
+// ReportQuery groups the filtered transactions into time buckets in Location. Buckets are
+// calendar units when Unit is set ("day", "month", ...), fixed Interval lengths otherwise.
+type ReportQuery struct {
+	TransactionFilter
+	Unit     string
+	Interval time.Duration
+	Location *time.Location
+}
+
+type ReportRepository interface {
+	Totals(ctx context.Context, query ReportQuery) ([]*entity.PeriodTotal, error)
+}
+
+type reportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// Totals returns per company, currency and bucket totals, ordered by bucket then company
+func (r *reportRepository) Totals(ctx context.Context, query ReportQuery) ([]*entity.PeriodTotal, error) {
+	where, args := query.where(nil)
+
+	args = append(args, query.Location.String())
+	zone := len(args)
+
+	// Fixed intervals are counted from a Monday midnight so weekly multiples align on weeks
+	var bucket string
+	if query.Unit != "" {
+		args = append(args, query.Unit)
+		bucket = fmt.Sprintf("date_trunc($%d, created_at, $%d)", len(args), zone)
+	} else {
+		args = append(args, fmt.Sprintf("%d seconds", int64(query.Interval/time.Second)))
+		bucket = fmt.Sprintf("date_bin($%d::INTERVAL, created_at AT TIME ZONE $%d, TIMESTAMP '2000-01-03') AT TIME ZONE $%d", len(args), zone, zone)
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT external_company_id, currency, %s AS bucket, COUNT(*),
+		       COALESCE(SUM(value) FILTER (WHERE type = 'in'), 0)::BIGINT,
+		       COALESCE(SUM(value) FILTER (WHERE type = 'out'), 0)::BIGINT
+		FROM transactions
+		WHERE %s
+		GROUP BY external_company_id, currency, bucket
+		ORDER BY bucket, external_company_id, currency`, bucket, where)
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*entity.PeriodTotal
+	for rows.Next() {
+		total := &entity.PeriodTotal{}
+		var in, out int64
+		if err := rows.Scan(&total.ExternalCompanyID, &total.Currency, &total.PeriodStart, &total.Count, &in, &out); err != nil {
+			return nil, err
+		}
+		total.PeriodStart = total.PeriodStart.In(query.Location)
+		total.TotalIn = money.NewMoneyFromMinorUnits(in, total.Currency)
+		total.TotalOut = money.NewMoneyFromMinorUnits(out, total.Currency)
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
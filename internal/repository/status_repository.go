@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"register-payment/internal/entity"
+)
+
+type StatusRepository interface {
+	Save(ctx context.Context, status *entity.TransactionStatus) error
+	Get(ctx context.Context, transactionID string) (*entity.TransactionStatus, error)
+}
+
+type statusRepository struct {
+	db *sql.DB
+}
+
+func NewStatusRepository(db *sql.DB) StatusRepository {
+	return &statusRepository{db: db}
+}
+
+// Save records the status of a transaction_id, adding status.Attempts to the stored count.
+// Registered is final: a later message reusing the transaction_id cannot replace it,
+// since the registered transaction is still the one stored.
+func (r *statusRepository) Save(ctx context.Context, status *entity.TransactionStatus) error {
+	query := `
+		INSERT INTO transaction_statuses (transaction_id, status, reason, attempts, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NOW(), NOW())
+		ON CONFLICT (transaction_id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    reason = EXCLUDED.reason,
+		    attempts = transaction_statuses.attempts + EXCLUDED.attempts,
+		    updated_at = EXCLUDED.updated_at
+		WHERE transaction_statuses.status <> 'registered' OR EXCLUDED.status = 'registered'`
+
+	_, err := r.db.ExecContext(ctx, query, status.TransactionID, status.Status, status.Reason, status.Attempts)
+	return err
+}
+
+func (r *statusRepository) Get(ctx context.Context, transactionID string) (*entity.TransactionStatus, error) {
+	query := `
+		SELECT transaction_id, status, COALESCE(reason, ''), attempts, created_at, updated_at
+		FROM transaction_statuses
+		WHERE transaction_id = $1`
+
+	status := &entity.TransactionStatus{}
+	err := r.db.QueryRowContext(ctx, query, transactionID).Scan(
+		&status.TransactionID,
+		&status.Status,
+		&status.Reason,
+		&status.Attempts,
+		&status.CreatedAt,
+		&status.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"fmt"
+	"register-payment/pkg/money"
+	"strings"
+	"time"
+)
+
+// TransactionFilter selects transactions; zero fields do not filter
+type TransactionFilter struct {
+	ExternalCompanyID string
+	Type              string
+	Currency          money.Currency
+	// MinValue and MaxValue are inclusive bounds. Values only compare within a currency,
+	// so a bound also restricts the results to its currency.
+	MinValue *money.Money
+	MaxValue *money.Money
+	// CreatedFrom is inclusive and CreatedTo exclusive
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// Description matches transactions whose description contains it, ignoring case
+	Description string
+}
+
+// Cursor is the position of a transaction in the listing order, newest first
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// TransactionQuery is a page of the filtered listing: up to Limit transactions after the cursor.
+// Keyset pagination keeps pages stable when transactions are inserted while paging.
+type TransactionQuery struct {
+	TransactionFilter
+	After *Cursor
+	Limit int
+}
+
+// where returns the SQL conditions of the filter, numbering placeholders after args
+func (f TransactionFilter) where(args []interface{}) (string, []interface{}) {
+	var conditions []string
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.ExternalCompanyID != "" {
+		add("external_company_id = $%d", f.ExternalCompanyID)
+	}
+	if f.Type != "" {
+		add("type = $%d", f.Type)
+	}
+	if f.Currency != "" {
+		add("currency = $%d", f.Currency)
+	}
+	if f.MinValue != nil {
+		add("currency = $%d", f.MinValue.Currency())
+		add("value >= $%d", f.MinValue.Cents())
+	}
+	if f.MaxValue != nil {
+		add("currency = $%d", f.MaxValue.Currency())
+		add("value <= $%d", f.MaxValue.Cents())
+	}
+	if !f.CreatedFrom.IsZero() {
+		add("created_at >= $%d", f.CreatedFrom)
+	}
+	if !f.CreatedTo.IsZero() {
+		add("created_at < $%d", f.CreatedTo)
+	}
+	if f.Description != "" {
+		add(`description ILIKE '%%' || $%d || '%%'`, escapeLike(f.Description))
+	}
+
+	if len(conditions) == 0 {
+		return "TRUE", args
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// escapeLike makes LIKE wildcards in s match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
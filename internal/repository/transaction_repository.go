@@ -1,19 +1,51 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"register-payment/internal/entity"
+	"register-payment/pkg/money"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// ErrDuplicate is matched by errors.Is when a transaction_id is already stored
+var ErrDuplicate = errors.New("duplicate transaction_id")
+
+// uniqueViolation is the PostgreSQL error code for a unique constraint violation
+const uniqueViolation = "23505"
+
+// DuplicateError reports that a transaction_id already exists. Existing holds the
+// stored row when it is known, so callers can compare it with what they tried to write.
+type DuplicateError struct {
+	TransactionID string
+	Existing      *entity.Transaction
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("transaction %s already exists", e.TransactionID)
+}
+
+// Is makes errors.Is(err, ErrDuplicate) match a *DuplicateError
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrDuplicate
+}
+
 type TransactionRepository interface {
-	Create(transaction *entity.Transaction) error
-	GetByID(id int) (*entity.Transaction, error)
-	GetByTransactionID(transactionID string) (*entity.Transaction, error)
-	GetByExternalCompanyID(externalCompanyID string) ([]*entity.Transaction, error)
-	List(limit, offset int) ([]*entity.Transaction, error)
-	Update(transaction *entity.Transaction) error
-	Delete(id int) error
+	Create(ctx context.Context, transaction *entity.Transaction, entry *entity.JournalEntry) error
+	CreateBatch(ctx context.Context, transactions []*entity.Transaction, entries []*entity.JournalEntry) ([]error, error)
+	GetByID(ctx context.Context, id int) (*entity.Transaction, error)
+	GetByTransactionID(ctx context.Context, transactionID string) (*entity.Transaction, error)
+	GetByExternalCompanyID(ctx context.Context, externalCompanyID string) ([]*entity.Transaction, error)
+	List(ctx context.Context, limit, offset int) ([]*entity.Transaction, error)
+	Search(ctx context.Context, query TransactionQuery) ([]*entity.Transaction, error)
+	Count(ctx context.Context, filter TransactionFilter) (int, error)
+	Stream(ctx context.Context, filter TransactionFilter, fn func(*entity.Transaction) error) error
+	Update(ctx context.Context, transaction *entity.Transaction, entry *entity.JournalEntry) error
+	Delete(ctx context.Context, id int) error
 }
 
 type transactionRepository struct {
@@ -24,170 +56,341 @@ func NewTransactionRepository(db *sql.DB) TransactionRepository {
 	return &transactionRepository{db: db}
 }
 
-func (r *transactionRepository) Create(transaction *entity.Transaction) error {
-	query := `
-		INSERT INTO transactions (transaction_id, value, type, external_company_id, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, created_at, updated_at`
+// Create inserts the transaction and posts the journal entry describing it, which updates
+// the company balance, all in one database transaction. A nil entry skips the ledger.
+//
+// Insertion is idempotent: if the transaction_id is already stored nothing is written
+// and a *DuplicateError holding the stored row is returned, even under concurrent inserts.
+func (r *transactionRepository) Create(ctx context.Context, transaction *entity.Transaction, entry *entity.JournalEntry) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		return insertTransaction(ctx, tx, transaction, entry)
+	})
+}
 
-	now := time.Now()
-	transaction.CreatedAt = now
-	transaction.UpdatedAt = now
+// CreateBatch creates the transactions like Create, with entries[i] describing transactions[i],
+// in a single database transaction. Duplicates do not abort the batch: their *DuplicateError
+// is returned at their index and the other transactions are still stored. Any other error
+// rolls the whole batch back.
+func (r *transactionRepository) CreateBatch(ctx context.Context, transactions []*entity.Transaction, entries []*entity.JournalEntry) ([]error, error) {
+	results := make([]error, len(transactions))
 
-	return r.db.QueryRow(
-		query,
-		transaction.TransactionID,
-		transaction.Value,
-		transaction.Type,
-		transaction.ExternalCompanyID,
-		transaction.Description,
-		transaction.CreatedAt,
-		transaction.UpdatedAt,
-	).Scan(&transaction.ID, &transaction.CreatedAt, &transaction.UpdatedAt)
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		for i, transaction := range transactions {
+			err := insertTransaction(ctx, tx, transaction, entries[i])
+			if errors.Is(err, ErrDuplicate) {
+				results[i] = err
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("transaction %s: %w", transaction.TransactionID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
 }
 
-func (r *transactionRepository) GetByID(id int) (*entity.Transaction, error) {
+func (r *transactionRepository) GetByID(ctx context.Context, id int) (*entity.Transaction, error) {
 	query := `
-		SELECT id, transaction_id, value, type, external_company_id, description, created_at, updated_at
+		SELECT id, transaction_id, value, currency, type, external_company_id, description, created_at, updated_at
 		FROM transactions
 		WHERE id = $1`
 
-	transaction := &entity.Transaction{}
-	err := r.db.QueryRow(query, id).Scan(
-		&transaction.ID,
-		&transaction.TransactionID,
-		&transaction.Value,
-		&transaction.Type,
-		&transaction.ExternalCompanyID,
-		&transaction.Description,
-		&transaction.CreatedAt,
-		&transaction.UpdatedAt,
-	)
+	return scanTransaction(r.db.QueryRowContext(ctx, query, id))
+}
 
+const selectByTransactionIDQuery = `
+		SELECT id, transaction_id, value, currency, type, external_company_id, description, created_at, updated_at
+		FROM transactions
+		WHERE transaction_id = $1`
+
+func (r *transactionRepository) GetByTransactionID(ctx context.Context, transactionID string) (*entity.Transaction, error) {
+	return scanTransaction(r.db.QueryRowContext(ctx, selectByTransactionIDQuery, transactionID))
+}
+
+func (r *transactionRepository) GetByExternalCompanyID(ctx context.Context, externalCompanyID string) ([]*entity.Transaction, error) {
+	query := `
+		SELECT id, transaction_id, value, currency, type, external_company_id, description, created_at, updated_at
+		FROM transactions
+		WHERE external_company_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, externalCompanyID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return transaction, nil
+	return scanTransactions(rows)
 }
 
-func (r *transactionRepository) GetByTransactionID(transactionID string) (*entity.Transaction, error) {
+func (r *transactionRepository) List(ctx context.Context, limit, offset int) ([]*entity.Transaction, error) {
 	query := `
-		SELECT id, transaction_id, value, type, external_company_id, description, created_at, updated_at
+		SELECT id, transaction_id, value, currency, type, external_company_id, description, created_at, updated_at
 		FROM transactions
-		WHERE transaction_id = $1`
-
-	transaction := &entity.Transaction{}
-	err := r.db.QueryRow(query, transactionID).Scan(
-		&transaction.ID,
-		&transaction.TransactionID,
-		&transaction.Value,
-		&transaction.Type,
-		&transaction.ExternalCompanyID,
-		&transaction.Description,
-		&transaction.CreatedAt,
-		&transaction.UpdatedAt,
-	)
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2`
 
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return transaction, nil
+	return scanTransactions(rows)
 }
 
-func (r *transactionRepository) GetByExternalCompanyID(externalCompanyID string) ([]*entity.Transaction, error) {
-	query := `
-		SELECT id, transaction_id, value, type, external_company_id, description, created_at, updated_at
+// Search returns the transactions matching the query, newest first, breaking ties by ID
+func (r *transactionRepository) Search(ctx context.Context, query TransactionQuery) ([]*entity.Transaction, error) {
+	where, args := query.where(nil)
+	if query.After != nil {
+		args = append(args, query.After.CreatedAt, query.After.ID)
+		where += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+	args = append(args, query.Limit)
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT id, transaction_id, value, currency, type, external_company_id, description, created_at, updated_at
 		FROM transactions
-		WHERE external_company_id = $1
-		ORDER BY created_at DESC`
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d`, where, len(args))
 
-	rows, err := r.db.Query(query, externalCompanyID)
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var transactions []*entity.Transaction
-	for rows.Next() {
-		transaction := &entity.Transaction{}
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.TransactionID,
-			&transaction.Value,
-			&transaction.Type,
-			&transaction.ExternalCompanyID,
-			&transaction.Description,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		transactions = append(transactions, transaction)
-	}
+	return scanTransactions(rows)
+}
+
+// Count returns how many transactions match the filter
+func (r *transactionRepository) Count(ctx context.Context, filter TransactionFilter) (int, error) {
+	where, args := filter.where(nil)
 
-	return transactions, nil
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM transactions WHERE "+where, args...).Scan(&count)
+	return count, err
 }
 
-func (r *transactionRepository) List(limit, offset int) ([]*entity.Transaction, error) {
+// Stream calls fn for every transaction matching the filter, oldest first, reading rows
+// one at a time instead of loading them all. An error from fn stops the iteration.
+func (r *transactionRepository) Stream(ctx context.Context, filter TransactionFilter, fn func(*entity.Transaction) error) error {
+	where, args := filter.where(nil)
+
 	query := `
-		SELECT id, transaction_id, value, type, external_company_id, description, created_at, updated_at
+		SELECT id, transaction_id, value, currency, type, external_company_id, description, created_at, updated_at
 		FROM transactions
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2`
+		WHERE ` + where + `
+		ORDER BY created_at, id`
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var transactions []*entity.Transaction
 	for rows.Next() {
-		transaction := &entity.Transaction{}
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.TransactionID,
-			&transaction.Value,
-			&transaction.Type,
-			&transaction.ExternalCompanyID,
-			&transaction.Description,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-		)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(transaction); err != nil {
+			return err
 		}
-		transactions = append(transactions, transaction)
 	}
 
-	return transactions, nil
+	return rows.Err()
 }
 
-func (r *transactionRepository) Update(transaction *entity.Transaction) error {
+// Update rewrites the transaction, reverses its previous journal entries and posts the new entry
+func (r *transactionRepository) Update(ctx context.Context, transaction *entity.Transaction, entry *entity.JournalEntry) error {
 	query := `
 		UPDATE transactions
-		SET value = $2, type = $3, external_company_id = $4, description = $5, updated_at = $6
+		SET transaction_id = $2, value = $3, currency = $4, type = $5, external_company_id = $6, description = $7, updated_at = $8
 		WHERE id = $1
 		RETURNING updated_at`
 
 	transaction.UpdatedAt = time.Now()
+	transaction.Currency = transaction.Value.Currency()
+
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		previous, err := lockTransaction(ctx, tx, transaction.ID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx,
+			query,
+			transaction.ID,
+			transaction.TransactionID,
+			transaction.Value,
+			transaction.Currency,
+			transaction.Type,
+			transaction.ExternalCompanyID,
+			transaction.Description,
+			transaction.UpdatedAt,
+		).Scan(&transaction.UpdatedAt)
+		if isUniqueViolation(err) {
+			return &DuplicateError{TransactionID: transaction.TransactionID}
+		}
+		if err != nil {
+			return err
+		}
+
+		// Reverse the old effect and apply the new one; company, currency or type may have
+		// changed. The transaction_id may have changed too, so reverse under the previous one.
+		if err := reverseJournalEntries(ctx, tx, previous.TransactionID, "update of transaction "+previous.TransactionID); err != nil {
+			return err
+		}
+		return postEntry(ctx, tx, transaction, entry)
+	})
+}
+
+func (r *transactionRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM transactions WHERE id = $1`
+
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		previous, err := lockTransaction(ctx, tx, id)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+
+		// Journal entries are never deleted; the removal is recorded as a reversal
+		return reverseJournalEntries(ctx, tx, previous.TransactionID, "deletion of transaction "+previous.TransactionID)
+	})
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+// withTx runs fn inside a database transaction, committing only if fn succeeds
+func (r *transactionRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertTransaction writes a new transaction with its journal effects inside tx.
+// A transaction_id already stored writes nothing and returns a *DuplicateError.
+func insertTransaction(ctx context.Context, tx *sql.Tx, transaction *entity.Transaction, entry *entity.JournalEntry) error {
+	query := `
+		INSERT INTO transactions (transaction_id, value, currency, type, external_company_id, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (transaction_id) DO NOTHING
+		RETURNING id, created_at, updated_at`
+
+	now := time.Now()
+	transaction.CreatedAt = now
+	transaction.UpdatedAt = now
+	transaction.Currency = transaction.Value.Currency()
 
-	return r.db.QueryRow(
+	err := tx.QueryRowContext(ctx,
 		query,
-		transaction.ID,
+		transaction.TransactionID,
 		transaction.Value,
+		transaction.Currency,
 		transaction.Type,
 		transaction.ExternalCompanyID,
 		transaction.Description,
+		transaction.CreatedAt,
 		transaction.UpdatedAt,
-	).Scan(&transaction.UpdatedAt)
+	).Scan(&transaction.ID, &transaction.CreatedAt, &transaction.UpdatedAt)
+	if err == sql.ErrNoRows {
+		// The conflicting row is committed by now, or was inserted earlier in tx, so this statement sees it
+		existing, err := scanTransaction(tx.QueryRowContext(ctx, selectByTransactionIDQuery, transaction.TransactionID))
+		if err != nil {
+			return err
+		}
+		return &DuplicateError{TransactionID: transaction.TransactionID, Existing: existing}
+	}
+	if err != nil {
+		return err
+	}
+
+	return postEntry(ctx, tx, transaction, entry)
 }
 
-func (r *transactionRepository) Delete(id int) error {
-	query := `DELETE FROM transactions WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
-}
\ No newline at end of file
+// postEntry links the journal entry to the transaction and posts it, if there is one
+func postEntry(ctx context.Context, tx *sql.Tx, transaction *entity.Transaction, entry *entity.JournalEntry) error {
+	if entry == nil {
+		return nil
+	}
+	entry.TransactionID = transaction.TransactionID
+	return postJournalEntry(ctx, tx, entry)
+}
+
+// lockTransaction reads a transaction row and locks it until tx ends
+func lockTransaction(ctx context.Context, tx *sql.Tx, id int) (*entity.Transaction, error) {
+	query := `
+		SELECT id, transaction_id, value, currency, type, external_company_id, description, created_at, updated_at
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE`
+
+	return scanTransaction(tx.QueryRowContext(ctx, query, id))
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTransaction(row rowScanner) (*entity.Transaction, error) {
+	transaction := &entity.Transaction{}
+	err := row.Scan(
+		&transaction.ID,
+		&transaction.TransactionID,
+		&transaction.Value,
+		&transaction.Currency,
+		&transaction.Type,
+		&transaction.ExternalCompanyID,
+		&transaction.Description,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The value column holds minor units only; attach the stored currency to it
+	transaction.Value = money.NewMoneyFromMinorUnits(transaction.Value.Cents(), transaction.Currency)
+
+	return transaction, nil
+}
+
+func scanTransactions(rows *sql.Rows) ([]*entity.Transaction, error) {
+	var transactions []*entity.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"register-payment/internal/entity"
+	"register-payment/pkg/money"
+	"testing"
+	"time"
+)
+
+// fakeBalanceRepository answers balance queries from fixed values and records the arguments
+type fakeBalanceRepository struct {
+	balance *entity.Balance
+	drifts  []*entity.BalanceDrift
+	err     error
+
+	companyID string
+	currency  money.Currency
+	at        time.Time
+}
+
+func (r *fakeBalanceRepository) Get(ctx context.Context, externalCompanyID string, currency money.Currency) (*entity.Balance, error) {
+	r.companyID, r.currency = externalCompanyID, currency
+	return r.balance, r.err
+}
+
+func (r *fakeBalanceRepository) GetAt(ctx context.Context, externalCompanyID string, currency money.Currency, at time.Time) (*entity.Balance, error) {
+	r.companyID, r.currency, r.at = externalCompanyID, currency, at
+	return r.balance, r.err
+}
+
+func (r *fakeBalanceRepository) ListByCompany(ctx context.Context, externalCompanyID string) ([]*entity.Balance, error) {
+	r.companyID = externalCompanyID
+	return []*entity.Balance{r.balance}, r.err
+}
+
+func (r *fakeBalanceRepository) FindDrift(ctx context.Context) ([]*entity.BalanceDrift, error) {
+	return r.drifts, r.err
+}
+
+func newBalanceService(balances *fakeBalanceRepository) TransactionService {
+	return NewTransactionService(nil, balances, nil, PostingRules{}, Timeouts{})
+}
+
+func TestGetBalanceAt(t *testing.T) {
+	at := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	balances := &fakeBalanceRepository{balance: &entity.Balance{
+		ExternalCompanyID: "COMP-1",
+		Currency:          money.USD,
+		Balance:           money.NewMoneyFromMinorUnits(9799, money.USD),
+		UpdatedAt:         at,
+	}}
+
+	balance, err := newBalanceService(balances).GetBalanceAt(context.Background(), "COMP-1", "usd", at)
+	if err != nil {
+		t.Fatalf("GetBalanceAt error: %v", err)
+	}
+	if balances.companyID != "COMP-1" || balances.currency != money.USD || !balances.at.Equal(at) {
+		t.Errorf("repository asked for %s %s at %s, want COMP-1 USD at %s", balances.companyID, balances.currency, balances.at, at)
+	}
+	if balance.Currency != "USD" || balance.Balance.Cents() != 9799 || !balance.AsOf.Equal(at) {
+		t.Errorf("balance = %+v, want 97.99 USD as of %s", balance, at)
+	}
+
+	if _, err := newBalanceService(balances).GetBalanceAt(context.Background(), "COMP-1", "XXX", at); !errors.Is(err, ErrValidation) {
+		t.Errorf("unknown currency error = %v, want ErrValidation", err)
+	}
+
+	balances.err = context.DeadlineExceeded
+	if _, err := newBalanceService(balances).GetBalanceAt(context.Background(), "COMP-1", "", at); !errors.Is(err, ErrTransient) {
+		t.Errorf("timed out query error = %v, want ErrTransient", err)
+	}
+	if balances.currency != money.DefaultCurrency {
+		t.Errorf("empty currency read as %s, want %s", balances.currency, money.DefaultCurrency)
+	}
+}
+
+func TestCheckBalances(t *testing.T) {
+	balances := &fakeBalanceRepository{drifts: []*entity.BalanceDrift{
+		{ExternalCompanyID: "COMP-1", Currency: money.BRL, Stored: money.NewMoneyFromCents(10050), Computed: money.NewMoneyFromCents(9799)},
+		{ExternalCompanyID: "COMP-2", Currency: money.BRL, Stored: money.NewMoneyFromCents(0), Computed: money.NewMoneyFromCents(500)},
+	}}
+
+	drifts, err := newBalanceService(balances).CheckBalances(context.Background())
+	if err != nil {
+		t.Fatalf("CheckBalances error: %v", err)
+	}
+	if len(drifts) != 2 {
+		t.Fatalf("got %d drifts, want 2", len(drifts))
+	}
+
+	expected := map[string]int64{"COMP-1": 251, "COMP-2": -500}
+	for _, drift := range drifts {
+		if drift.Currency != "BRL" || drift.Drift.Cents() != expected[drift.ExternalCompanyID] {
+			t.Errorf("%s drift = %s %s, want %d cents BRL", drift.ExternalCompanyID, drift.Drift, drift.Currency, expected[drift.ExternalCompanyID])
+		}
+	}
+
+	balances.drifts, balances.err = nil, errors.New("connection reset")
+	if _, err := newBalanceService(balances).CheckBalances(context.Background()); err == nil || errors.Is(err, ErrValidation) {
+		t.Errorf("repository failure error = %v, want an internal error", err)
+	}
+}
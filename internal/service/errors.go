@@ -0,0 +1,63 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"register-payment/internal/repository"
+	"register-payment/pkg/database"
+	"register-payment/pkg/money"
+)
+
+var (
+	// ErrNotFound is returned when the requested transaction does not exist
+	ErrNotFound = errors.New("transaction not found")
+	// ErrDuplicate is returned by CreateTransaction when the same transaction was already
+	// registered with identical content; the stored transaction is returned alongside it
+	ErrDuplicate = errors.New("transaction already registered")
+	// ErrConflict is returned when a transaction_id is already registered with different content
+	ErrConflict = errors.New("transaction_id already registered with different content")
+	// ErrValidation is matched by errors.Is for every *ValidationError
+	ErrValidation = errors.New("invalid transaction")
+	// ErrTransient marks temporary storage failures that may succeed if retried
+	ErrTransient = errors.New("temporary storage failure")
+)
+
+// ValidationError reports a request the service cannot accept whatever the retries
+type ValidationError struct {
+	Field string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %v", e.Field, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrValidation) match a *ValidationError
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// wrapRepoError adds the operation to a repository error and classifies it so callers
+// can decide with errors.Is instead of matching strings
+func wrapRepoError(op string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%s: %w", op, ErrNotFound)
+	case errors.Is(err, repository.ErrDuplicate):
+		return fmt.Errorf("%s: %w: %w", op, ErrConflict, err)
+	case errors.Is(err, money.ErrCurrencyMismatch),
+		errors.Is(err, money.ErrOverflow):
+		return fmt.Errorf("%s: %w", op, &ValidationError{Field: "value", Err: err})
+	case database.IsTransient(err):
+		return fmt.Errorf("%s: %w: %w", op, ErrTransient, err)
+	default:
+		return fmt.Errorf("%s: %w", op, err)
+	}
+}
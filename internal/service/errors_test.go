@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"register-payment/internal/entity"
+	"register-payment/internal/repository"
+	"register-payment/pkg/money"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestWrapRepoError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"not found", sql.ErrNoRows, ErrNotFound},
+		{"duplicate", &repository.DuplicateError{TransactionID: "TXN-1"}, ErrConflict},
+		{"currency mismatch", &money.CurrencyMismatchError{Left: money.BRL, Right: money.USD}, ErrValidation},
+		{"deadlock", &pq.Error{Code: "40P01"}, ErrTransient},
+		{"connection lost", &pq.Error{Code: "08006"}, ErrTransient},
+		{"timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), ErrTransient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapRepoError("get transaction 1", tt.err)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("wrapRepoError(%v) = %v, want it to match %v", tt.err, err, tt.expected)
+			}
+			if !errors.Is(err, tt.err) && !errors.Is(err, ErrNotFound) {
+				t.Errorf("wrapRepoError(%v) = %v, lost the original error", tt.err, err)
+			}
+		})
+	}
+
+	// Check violations and unbalanced entries are bugs on our side, not bad input
+	for _, internal := range []error{&pq.Error{Code: "23514"}, fmt.Errorf("post entry: %w", entity.ErrUnbalancedEntry)} {
+		err := wrapRepoError("create transaction TXN-1", internal)
+		for _, sentinel := range []error{ErrNotFound, ErrConflict, ErrValidation, ErrTransient} {
+			if errors.Is(err, sentinel) {
+				t.Errorf("%v classified as %v", internal, sentinel)
+			}
+		}
+	}
+
+	if wrapRepoError("op", nil) != nil {
+		t.Error("wrapRepoError(nil) != nil")
+	}
+}
+
+func TestValidationErrorAs(t *testing.T) {
+	_, err := parseCurrency("XYZ")
+
+	var validation *ValidationError
+	if !errors.As(err, &validation) || validation.Field != "currency" {
+		t.Fatalf("parseCurrency error %v is not a currency *ValidationError", err)
+	}
+	if !errors.Is(err, money.ErrUnknownCurrency) {
+		t.Errorf("%v does not unwrap to ErrUnknownCurrency", err)
+	}
+}
+
+func TestEntryError(t *testing.T) {
+	_, unsupported := PostingRules{}.Entry(&entity.Transaction{Type: "transfer", Value: money.NewMoneyFromCents(100)})
+
+	tests := []struct {
+		name  string
+		err   error
+		field string
+	}{
+		{"unsupported type", unsupported, "type"},
+		{"fee overflow", fmt.Errorf("failed to compute fee: %w", money.ErrOverflow), "value"},
+		{"unbalanced entry", fmt.Errorf("%w: lines sum to 0.01", entity.ErrUnbalancedEntry), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := entryError(tt.err)
+			var validation *ValidationError
+			switch {
+			case tt.field == "" && errors.As(err, &validation):
+				t.Errorf("entryError(%v) = %v, want it left unclassified", tt.err, err)
+			case tt.field != "" && (!errors.As(err, &validation) || validation.Field != tt.field):
+				t.Errorf("entryError(%v) = %v, want a validation error on %s", tt.err, err, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"register-payment/internal/dto"
+	"register-payment/internal/entity"
+	"register-payment/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// exportColumn writes one field of a transaction: text for CSV cells and a value for JSON.
+// Money is written as a decimal in its currency's minor units, never as a float.
+type exportColumn struct {
+	name string
+	text func(t *entity.Transaction) string
+	json func(t *entity.Transaction) interface{}
+}
+
+var exportColumns = []exportColumn{
+	{"id", func(t *entity.Transaction) string { return strconv.Itoa(t.ID) }, func(t *entity.Transaction) interface{} { return t.ID }},
+	{"transaction_id", func(t *entity.Transaction) string { return t.TransactionID }, func(t *entity.Transaction) interface{} { return t.TransactionID }},
+	{"value", func(t *entity.Transaction) string { return t.Value.String() }, func(t *entity.Transaction) interface{} { return t.Value }},
+	{"currency", func(t *entity.Transaction) string { return t.Value.Currency().String() }, func(t *entity.Transaction) interface{} { return t.Value.Currency() }},
+	{"type", func(t *entity.Transaction) string { return t.Type }, func(t *entity.Transaction) interface{} { return t.Type }},
+	{"external_company_id", func(t *entity.Transaction) string { return t.ExternalCompanyID }, func(t *entity.Transaction) interface{} { return t.ExternalCompanyID }},
+	{"description", func(t *entity.Transaction) string { return t.Description }, func(t *entity.Transaction) interface{} { return t.Description }},
+	{"created_at", func(t *entity.Transaction) string { return t.CreatedAt.UTC().Format(time.RFC3339Nano) }, func(t *entity.Transaction) interface{} { return t.CreatedAt.UTC() }},
+	{"updated_at", func(t *entity.Transaction) string { return t.UpdatedAt.UTC().Format(time.RFC3339Nano) }, func(t *entity.Transaction) interface{} { return t.UpdatedAt.UTC() }},
+}
+
+type ExportService interface {
+	NewExport(query *dto.ExportQuery) (*Export, error)
+}
+
+type exportService struct {
+	repo     repository.TransactionRepository
+	timeouts Timeouts
+}
+
+// NewExportService creates the export service; exports are bounded by timeouts.Export
+func NewExportService(repo repository.TransactionRepository, timeouts Timeouts) ExportService {
+	return &exportService{repo: repo, timeouts: timeouts}
+}
+
+// Export is a validated export ready to be written. Validation happens before anything
+// is written, so an HTTP handler can still answer with an error status.
+type Export struct {
+	repo    repository.TransactionRepository
+	timeout time.Duration
+	filter  repository.TransactionFilter
+	format  string
+	columns []exportColumn
+}
+
+// NewExport validates the query and returns the export it describes
+func (s *exportService) NewExport(q *dto.ExportQuery) (*Export, error) {
+	filter, err := parseFilter(&q.TransactionFilter, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+
+	format := strings.ToLower(q.Format)
+	switch format {
+	case "":
+		format = ExportFormatCSV
+	case ExportFormatCSV, ExportFormatNDJSON:
+	default:
+		return nil, &ValidationError{Field: "format", Err: errors.New("must be csv or ndjson")}
+	}
+
+	columns, err := parseColumns(q.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Export{repo: s.repo, timeout: s.timeouts.Export, filter: filter, format: format, columns: columns}, nil
+}
+
+// Format returns ExportFormatCSV or ExportFormatNDJSON
+func (e *Export) Format() string {
+	return e.format
+}
+
+// ContentType returns the MIME type of the export
+func (e *Export) ContentType() string {
+	if e.format == ExportFormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// WriteTo streams the matching transactions to w as they are read from the database.
+// A CSV export starts with a header row; an NDJSON export writes one object per line.
+func (e *Export) WriteTo(ctx context.Context, w io.Writer) error {
+	ctx, cancel := withTimeout(ctx, e.timeout)
+	defer cancel()
+
+	var write func(t *entity.Transaction) error
+	var flush func() error
+
+	if e.format == ExportFormatNDJSON {
+		buffered := bufio.NewWriter(w)
+		write = func(t *entity.Transaction) error { return e.writeJSON(buffered, t) }
+		flush = buffered.Flush
+	} else {
+		writer := csv.NewWriter(w)
+		header := make([]string, len(e.columns))
+		for i, column := range e.columns {
+			header[i] = column.name
+		}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		record := make([]string, len(e.columns))
+		write = func(t *entity.Transaction) error {
+			for i, column := range e.columns {
+				record[i] = column.text(t)
+			}
+			return writer.Write(record)
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	}
+
+	if err := e.repo.Stream(ctx, e.filter, write); err != nil {
+		return wrapRepoError("export transactions", err)
+	}
+	return flush()
+}
+
+// writeJSON writes the transaction as a JSON object with the columns in order
+func (e *Export) writeJSON(w *bufio.Writer, t *entity.Transaction) error {
+	w.WriteByte('{')
+	for i, column := range e.columns {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		value, err := json.Marshal(column.json(t))
+		if err != nil {
+			return fmt.Errorf("export column %s of transaction %s: %w", column.name, t.TransactionID, err)
+		}
+		w.WriteString(strconv.Quote(column.name))
+		w.WriteByte(':')
+		w.Write(value)
+	}
+	w.WriteByte('}')
+	return w.WriteByte('\n')
+}
+
+// parseColumns returns the named export columns in the given order, or all of them
+func parseColumns(names string) ([]exportColumn, error) {
+	if strings.TrimSpace(names) == "" {
+		return exportColumns, nil
+	}
+
+	var columns []exportColumn
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		column, ok := findColumn(name)
+		if !ok {
+			return nil, &ValidationError{Field: "columns", Err: fmt.Errorf("unknown column %q", name)}
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func findColumn(name string) (exportColumn, bool) {
+	for _, column := range exportColumns {
+		if column.name == name {
+			return column, true
+		}
+	}
+	return exportColumn{}, false
+}
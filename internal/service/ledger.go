@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"register-payment/internal/entity"
+	"register-payment/pkg/money"
+)
+
+// ErrUnsupportedType is returned by Entry for a transaction type it has no rule for
+var ErrUnsupportedType = errors.New("unsupported transaction type")
+
+// PostingRules turns a transaction into a balanced journal entry
+type PostingRules struct {
+	// FeeBasisPoints is charged on incoming payments and credited to the fee revenue account
+	FeeBasisPoints int64
+	// FeeRounding rounds fees that fall between two minor units
+	FeeRounding money.RoundingMode
+}
+
+// Entry builds the journal entry for a transaction.
+//
+// An incoming payment debits clearing with the full value, credits the company's
+// customer account with the value net of fees and credits fee revenue with the fee.
+// An outgoing payment debits the customer account and credits clearing.
+func (r PostingRules) Entry(transaction *entity.Transaction) (*entity.JournalEntry, error) {
+	value := transaction.Value
+	customer := entity.JournalLine{
+		AccountCode:       entity.CustomerAccountCode(transaction.ExternalCompanyID),
+		AccountType:       entity.AccountTypeLiability,
+		ExternalCompanyID: transaction.ExternalCompanyID,
+	}
+	clearing := entity.JournalLine{
+		AccountCode: entity.AccountCodeClearing,
+		AccountType: entity.AccountTypeAsset,
+	}
+
+	entry := &entity.JournalEntry{
+		TransactionID: transaction.TransactionID,
+		Kind:          entity.JournalKindPayment,
+		Description:   transaction.Description,
+	}
+
+	switch transaction.Type {
+	case entity.TransactionTypeIn:
+		fee, err := value.ApplyBasisPoints(r.FeeBasisPoints, r.FeeRounding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute fee: %w", err)
+		}
+		net, err := value.Subtract(fee)
+		if err != nil {
+			return nil, err
+		}
+
+		clearing.Amount = value
+		customer.Amount = net.Negate()
+		entry.Lines = []entity.JournalLine{clearing, customer}
+		if !fee.IsZero() {
+			entry.Lines = append(entry.Lines, entity.JournalLine{
+				AccountCode: entity.AccountCodeFeeRevenue,
+				AccountType: entity.AccountTypeRevenue,
+				Amount:      fee.Negate(),
+			})
+		}
+	case entity.TransactionTypeOut:
+		customer.Amount = value
+		clearing.Amount = value.Negate()
+		entry.Lines = []entity.JournalLine{customer, clearing}
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedType, transaction.Type)
+	}
+
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"register-payment/internal/entity"
+	"register-payment/pkg/money"
+	"testing"
+)
+
+func TestPostingRulesEntry(t *testing.T) {
+	rules := PostingRules{FeeBasisPoints: 250, FeeRounding: money.RoundHalfEven}
+
+	tests := []struct {
+		name     string
+		txType   string
+		cents    int64
+		expected map[string]int64
+	}{
+		{"incoming with fee", entity.TransactionTypeIn, 10050, map[string]int64{
+			entity.AccountCodeClearing:   10050,
+			"customer:COMP-1":            -9799,
+			entity.AccountCodeFeeRevenue: -251,
+		}},
+		{"incoming below fee unit", entity.TransactionTypeIn, 10, map[string]int64{
+			entity.AccountCodeClearing: 10,
+			"customer:COMP-1":          -10,
+		}},
+		{"outgoing", entity.TransactionTypeOut, 5000, map[string]int64{
+			"customer:COMP-1":          5000,
+			entity.AccountCodeClearing: -5000,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := rules.Entry(&entity.Transaction{
+				TransactionID:     "TXN-1",
+				Value:             money.NewMoneyFromCents(tt.cents),
+				Type:              tt.txType,
+				ExternalCompanyID: "COMP-1",
+			})
+			if err != nil {
+				t.Fatalf("Entry error: %v", err)
+			}
+			if len(entry.Lines) != len(tt.expected) {
+				t.Fatalf("got %d lines, want %d", len(entry.Lines), len(tt.expected))
+			}
+			for _, line := range entry.Lines {
+				if line.Amount.Cents() != tt.expected[line.AccountCode] {
+					t.Errorf("%s = %d, want %d", line.AccountCode, line.Amount.Cents(), tt.expected[line.AccountCode])
+				}
+			}
+		})
+	}
+}
+
+func TestJournalEntryValidate(t *testing.T) {
+	entry := &entity.JournalEntry{Lines: []entity.JournalLine{
+		{AccountCode: "a", Amount: money.NewMoneyFromCents(100)},
+		{AccountCode: "b", Amount: money.NewMoneyFromCents(-99)},
+	}}
+	if err := entry.Validate(); err == nil {
+		t.Error("unbalanced entry passed validation")
+	}
+
+	entry.Lines[1].Amount = money.NewMoneyFromMinorUnits(-100, money.USD)
+	if err := entry.Validate(); err == nil {
+		t.Error("entry mixing currencies passed validation")
+	}
+}
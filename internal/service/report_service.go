@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"register-payment/internal/dto"
+	"register-payment/internal/repository"
+	"time"
+)
+
+// calendarUnits are the bucket names grouped by calendar rather than by fixed length
+var calendarUnits = map[string]bool{
+	"hour":    true,
+	"day":     true,
+	"week":    true,
+	"month":   true,
+	"quarter": true,
+	"year":    true,
+}
+
+type ReportService interface {
+	GetTotals(ctx context.Context, query *dto.ReportQuery) (*dto.ReportResponse, error)
+}
+
+type reportService struct {
+	repo     repository.ReportRepository
+	timeouts Timeouts
+}
+
+func NewReportService(repo repository.ReportRepository, timeouts Timeouts) ReportService {
+	return &reportService{repo: repo, timeouts: timeouts}
+}
+
+// GetTotals returns count, sum in, sum out and net per company, currency and time bucket.
+// Bucket defaults to day and TimeZone to UTC.
+func (s *reportService) GetTotals(ctx context.Context, q *dto.ReportQuery) (*dto.ReportResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	query, err := parseReportQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.repo.Totals(ctx, query)
+	if err != nil {
+		return nil, wrapRepoError("report totals", err)
+	}
+
+	response := &dto.ReportResponse{
+		Bucket:   q.Bucket,
+		TimeZone: query.Location.String(),
+		Totals:   make([]*dto.PeriodTotalResponse, 0, len(totals)),
+	}
+	if response.Bucket == "" {
+		response.Bucket = query.Unit
+	}
+	for _, total := range totals {
+		net, err := total.TotalIn.Subtract(total.TotalOut)
+		if err != nil {
+			return nil, fmt.Errorf("net of %s %s: %w", total.ExternalCompanyID, total.Currency, err)
+		}
+		response.Totals = append(response.Totals, &dto.PeriodTotalResponse{
+			ExternalCompanyID: total.ExternalCompanyID,
+			Currency:          total.Currency.String(),
+			PeriodStart:       total.PeriodStart,
+			Count:             total.Count,
+			TotalIn:           total.TotalIn,
+			TotalOut:          total.TotalOut,
+			Net:               net,
+		})
+	}
+
+	return response, nil
+}
+
+func parseReportQuery(q *dto.ReportQuery) (repository.ReportQuery, error) {
+	query := repository.ReportQuery{Location: time.UTC}
+
+	if q.TimeZone != "" {
+		loc, err := time.LoadLocation(q.TimeZone)
+		if err != nil {
+			return query, &ValidationError{Field: "time_zone", Err: err}
+		}
+		query.Location = loc
+	}
+
+	filter, err := parseFilter(&q.TransactionFilter, query.Location)
+	if err != nil {
+		return query, err
+	}
+	query.TransactionFilter = filter
+
+	switch {
+	case q.Bucket == "":
+		query.Unit = "day"
+	case calendarUnits[q.Bucket]:
+		query.Unit = q.Bucket
+	default:
+		interval, err := time.ParseDuration(q.Bucket)
+		if err != nil {
+			return query, &ValidationError{Field: "bucket", Err: errors.New("must be hour, day, week, month, quarter, year or a duration such as 15m")}
+		}
+		if interval < time.Second || interval%time.Second != 0 {
+			return query, &ValidationError{Field: "bucket", Err: errors.New("duration must be a whole number of seconds")}
+		}
+		query.Interval = interval
+	}
+
+	return query, nil
+}
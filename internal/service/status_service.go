@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"register-payment/internal/dto"
+	"register-payment/internal/entity"
+	"register-payment/internal/repository"
+)
+
+// StatusService tracks each transaction_id from the moment it is queued until it is
+// registered, rejected or dead-lettered
+type StatusService interface {
+	MarkQueued(ctx context.Context, transactionID string) error
+	MarkProcessing(ctx context.Context, transactionID string) error
+	MarkRegistered(ctx context.Context, transactionID string) error
+	MarkRejected(ctx context.Context, transactionID, reason string) error
+	MarkDeadLettered(ctx context.Context, transactionID, reason string) error
+	GetStatus(ctx context.Context, transactionID string) (*dto.TransactionStatusResponse, error)
+}
+
+type statusService struct {
+	repo     repository.StatusRepository
+	timeouts Timeouts
+}
+
+func NewStatusService(repo repository.StatusRepository, timeouts Timeouts) StatusService {
+	return &statusService{repo: repo, timeouts: timeouts}
+}
+
+func (s *statusService) MarkQueued(ctx context.Context, transactionID string) error {
+	return s.save(ctx, &entity.TransactionStatus{TransactionID: transactionID, Status: entity.StatusQueued})
+}
+
+// MarkProcessing records that a delivery of the transaction is being processed, counting the attempt
+func (s *statusService) MarkProcessing(ctx context.Context, transactionID string) error {
+	return s.save(ctx, &entity.TransactionStatus{TransactionID: transactionID, Status: entity.StatusProcessing, Attempts: 1})
+}
+
+func (s *statusService) MarkRegistered(ctx context.Context, transactionID string) error {
+	return s.save(ctx, &entity.TransactionStatus{TransactionID: transactionID, Status: entity.StatusRegistered})
+}
+
+func (s *statusService) MarkRejected(ctx context.Context, transactionID, reason string) error {
+	return s.save(ctx, &entity.TransactionStatus{TransactionID: transactionID, Status: entity.StatusRejected, Reason: reason})
+}
+
+func (s *statusService) MarkDeadLettered(ctx context.Context, transactionID, reason string) error {
+	return s.save(ctx, &entity.TransactionStatus{TransactionID: transactionID, Status: entity.StatusDeadLettered, Reason: reason})
+}
+
+func (s *statusService) GetStatus(ctx context.Context, transactionID string) (*dto.TransactionStatusResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	status, err := s.repo.Get(ctx, transactionID)
+	if err != nil {
+		return nil, wrapRepoError("get status of transaction "+transactionID, err)
+	}
+
+	return &dto.TransactionStatusResponse{
+		TransactionID: status.TransactionID,
+		Status:        status.Status,
+		Reason:        status.Reason,
+		Attempts:      status.Attempts,
+		CreatedAt:     status.CreatedAt,
+		UpdatedAt:     status.UpdatedAt,
+	}, nil
+}
+
+func (s *statusService) save(ctx context.Context, status *entity.TransactionStatus) error {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	return wrapRepoError("record status "+status.Status+" of transaction "+status.TransactionID, s.repo.Save(ctx, status))
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"register-payment/internal/dto"
+	"register-payment/internal/entity"
+	"register-payment/internal/repository"
+	"register-payment/pkg/money"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errInvalidCursor = errors.New("malformed cursor")
+
+// parseQuery converts a listing request into a repository query, clamping the limit like ListTransactions
+func parseQuery(q *dto.TransactionQuery) (repository.TransactionQuery, error) {
+	filter, err := parseFilter(&q.TransactionFilter, time.UTC)
+	if err != nil {
+		return repository.TransactionQuery{}, err
+	}
+	query := repository.TransactionQuery{TransactionFilter: filter, Limit: q.Limit}
+
+	if q.Cursor != "" {
+		cursor, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return query, &ValidationError{Field: "cursor", Err: err}
+		}
+		query.After = cursor
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = 10
+	}
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+
+	return query, nil
+}
+
+// parseFilter converts request filters; dates without a time are midnight in loc
+func parseFilter(f *dto.TransactionFilter, loc *time.Location) (repository.TransactionFilter, error) {
+	filter := repository.TransactionFilter{
+		ExternalCompanyID: f.ExternalCompanyID,
+		Description:       f.Description,
+	}
+
+	switch f.Type {
+	case "", entity.TransactionTypeIn, entity.TransactionTypeOut:
+		filter.Type = f.Type
+	default:
+		return filter, &ValidationError{Field: "type", Err: errors.New("must be in or out")}
+	}
+
+	currency, err := parseCurrency(f.Currency)
+	if err != nil {
+		return filter, err
+	}
+	if f.Currency != "" {
+		filter.Currency = currency
+	}
+
+	if filter.MinValue, err = parseBound("min_value", f.MinValue, currency); err != nil {
+		return filter, err
+	}
+	if filter.MaxValue, err = parseBound("max_value", f.MaxValue, currency); err != nil {
+		return filter, err
+	}
+
+	if filter.CreatedFrom, err = parseTime("created_from", f.CreatedFrom, loc); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseTime("created_to", f.CreatedTo, loc); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+func parseBound(field, value string, currency money.Currency) (*money.Money, error) {
+	if value == "" {
+		return nil, nil
+	}
+	bound, err := money.NewMoneyFromStringInCurrency(value, currency)
+	if err != nil {
+		return nil, &ValidationError{Field: field, Err: err}
+	}
+	return &bound, nil
+}
+
+func parseTime(field, value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, &ValidationError{Field: field, Err: err}
+	}
+	return t, nil
+}
+
+// encodeCursor returns an opaque token for the position after transaction
+func encodeCursor(transaction *entity.Transaction) string {
+	raw := strconv.FormatInt(transaction.CreatedAt.UnixNano(), 10) + ":" + strconv.Itoa(transaction.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(token string) (*repository.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	return &repository.Cursor{CreatedAt: time.Unix(0, n).UTC(), ID: i}, nil
+}
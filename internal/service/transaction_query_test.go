@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"register-payment/internal/dto"
+	"register-payment/internal/entity"
+	"register-payment/pkg/money"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	transaction := &entity.Transaction{ID: 42, CreatedAt: time.Date(2025, 8, 4, 12, 0, 0, 123456000, time.UTC)}
+
+	cursor, err := decodeCursor(encodeCursor(transaction))
+	if err != nil {
+		t.Fatalf("decodeCursor error: %v", err)
+	}
+	if cursor.ID != 42 || !cursor.CreatedAt.Equal(transaction.CreatedAt) {
+		t.Errorf("cursor = %+v, want ID 42 at %v", cursor, transaction.CreatedAt)
+	}
+
+	for _, token := range []string{"!!", "MTIz", "YWJjOjE"} {
+		if _, err := decodeCursor(token); err == nil {
+			t.Errorf("decodeCursor(%q) accepted a malformed cursor", token)
+		}
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	query, err := parseQuery(&dto.TransactionQuery{
+		TransactionFilter: dto.TransactionFilter{
+			Type:        "in",
+			Currency:    "JPY",
+			MinValue:    "100",
+			CreatedFrom: "2025-08-01T00:00:00-03:00",
+			CreatedTo:   "2025-09-01",
+		},
+		Limit: 500,
+	})
+	if err != nil {
+		t.Fatalf("parseQuery error: %v", err)
+	}
+	if query.MinValue == nil || query.MinValue.Cents() != 100 || query.MinValue.Currency() != money.JPY {
+		t.Errorf("MinValue = %v, want JPY 100", query.MinValue)
+	}
+	if query.Limit != 100 {
+		t.Errorf("Limit = %d, want it clamped to 100", query.Limit)
+	}
+	if !query.CreatedFrom.Equal(time.Date(2025, 8, 1, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("CreatedFrom = %v", query.CreatedFrom)
+	}
+	if !query.CreatedTo.Equal(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("CreatedTo = %v", query.CreatedTo)
+	}
+
+	invalid := []dto.TransactionQuery{
+		{TransactionFilter: dto.TransactionFilter{Type: "both"}},
+		{TransactionFilter: dto.TransactionFilter{Currency: "XYZ"}},
+		{TransactionFilter: dto.TransactionFilter{MaxValue: "1.234"}},
+		{TransactionFilter: dto.TransactionFilter{CreatedTo: "01/08/2025"}},
+		{Cursor: "not a cursor"},
+	}
+	for _, q := range invalid {
+		if _, err := parseQuery(&q); !errors.Is(err, ErrValidation) {
+			t.Errorf("parseQuery(%+v) error = %v, want ErrValidation", q, err)
+		}
+	}
+}
@@ -1,83 +1,191 @@
 package service
 
 import (
-	"database/sql"
+	"context"
 	"errors"
+	"fmt"
 	"register-payment/internal/dto"
 	"register-payment/internal/entity"
 	"register-payment/internal/repository"
+	"register-payment/pkg/money"
+	"time"
 )
 
 type TransactionService interface {
-	CreateTransaction(req *dto.TransactionRequest) (*dto.TransactionResponse, error)
-	GetTransaction(id int) (*dto.TransactionResponse, error)
-	GetTransactionByID(transactionID string) (*dto.TransactionResponse, error)
-	GetTransactionsByCompany(externalCompanyID string) ([]*dto.TransactionResponse, error)
-	ListTransactions(limit, offset int) ([]*dto.TransactionResponse, error)
-	UpdateTransaction(id int, req *dto.TransactionRequest) (*dto.TransactionResponse, error)
-	DeleteTransaction(id int) error
+	CreateTransaction(ctx context.Context, req *dto.TransactionRequest) (*dto.TransactionResponse, error)
+	CreateTransactions(ctx context.Context, reqs []*dto.TransactionRequest) ([]error, error)
+	GetTransaction(ctx context.Context, id int) (*dto.TransactionResponse, error)
+	GetTransactionByID(ctx context.Context, transactionID string) (*dto.TransactionResponse, error)
+	GetTransactionsByCompany(ctx context.Context, externalCompanyID string) ([]*dto.TransactionResponse, error)
+	ListTransactions(ctx context.Context, limit, offset int) ([]*dto.TransactionResponse, error)
+	SearchTransactions(ctx context.Context, query *dto.TransactionQuery) (*dto.TransactionPage, error)
+	UpdateTransaction(ctx context.Context, id int, req *dto.TransactionRequest) (*dto.TransactionResponse, error)
+	DeleteTransaction(ctx context.Context, id int) error
+	GetBalance(ctx context.Context, externalCompanyID, currency string) (*dto.BalanceResponse, error)
+	GetBalanceAt(ctx context.Context, externalCompanyID, currency string, at time.Time) (*dto.BalanceResponse, error)
+	CheckBalances(ctx context.Context) ([]*dto.BalanceDriftResponse, error)
+	GetJournal(ctx context.Context, transactionID string) ([]*dto.JournalEntryResponse, error)
+	GetAccountBalance(ctx context.Context, code, currency string) (*dto.AccountBalanceResponse, error)
+}
+
+// Timeouts bounds how long each kind of operation may spend in the database.
+// A zero duration leaves the caller's deadline, if any, as the only limit.
+type Timeouts struct {
+	Read   time.Duration
+	Write  time.Duration
+	Export time.Duration
 }
 
 type transactionService struct {
-	repo repository.TransactionRepository
+	repo     repository.TransactionRepository
+	balances repository.BalanceRepository
+	ledger   repository.LedgerRepository
+	rules    PostingRules
+	timeouts Timeouts
 }
 
-func NewTransactionService(repo repository.TransactionRepository) TransactionService {
-	return &transactionService{repo: repo}
+func NewTransactionService(repo repository.TransactionRepository, balances repository.BalanceRepository, ledger repository.LedgerRepository, rules PostingRules, timeouts Timeouts) TransactionService {
+	return &transactionService{repo: repo, balances: balances, ledger: ledger, rules: rules, timeouts: timeouts}
 }
 
-func (s *transactionService) CreateTransaction(req *dto.TransactionRequest) (*dto.TransactionResponse, error) {
-	existing, err := s.repo.GetByTransactionID(req.TransactionID)
-	if err != nil && err != sql.ErrNoRows {
+// CreateTransaction registers a transaction exactly once. Repeating the same request returns
+// the stored transaction together with ErrDuplicate; reusing its transaction_id for different
+// content returns ErrConflict.
+func (s *transactionService) CreateTransaction(ctx context.Context, req *dto.TransactionRequest) (*dto.TransactionResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	transaction, entry, err := s.newTransaction(req)
+	if err != nil {
 		return nil, err
 	}
-	if existing != nil {
-		return nil, errors.New("transaction with this ID already exists")
+
+	err = s.repo.Create(ctx, transaction, entry)
+	var duplicate *repository.DuplicateError
+	if errors.As(err, &duplicate) {
+		return s.resolveDuplicate(duplicate, transaction)
+	}
+	if err != nil {
+		return nil, wrapRepoError("create transaction "+transaction.TransactionID, err)
+	}
+
+	return s.entityToResponse(transaction), nil
+}
+
+// CreateTransactions registers a batch of transactions in one database transaction. The
+// returned slice holds each request's outcome as CreateTransaction would report it: nil,
+// ErrDuplicate, ErrConflict or a validation error. Any other failure fails the whole batch.
+func (s *transactionService) CreateTransactions(ctx context.Context, reqs []*dto.TransactionRequest) ([]error, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	results := make([]error, len(reqs))
+	var batch []*entity.Transaction
+	var entries []*entity.JournalEntry
+	var indexes []int
+	for i, req := range reqs {
+		transaction, entry, err := s.newTransaction(req)
+		if err != nil {
+			results[i] = err
+			continue
+		}
+		batch = append(batch, transaction)
+		entries = append(entries, entry)
+		indexes = append(indexes, i)
+	}
+	if len(batch) == 0 {
+		return results, nil
+	}
+
+	batchResults, err := s.repo.CreateBatch(ctx, batch, entries)
+	if err != nil {
+		return nil, wrapRepoError(fmt.Sprintf("create batch of %d transactions", len(batch)), err)
+	}
+
+	for j, err := range batchResults {
+		var duplicate *repository.DuplicateError
+		if errors.As(err, &duplicate) {
+			_, err = s.resolveDuplicate(duplicate, batch[j])
+		}
+		results[indexes[j]] = err
 	}
 
+	return results, nil
+}
+
+// newTransaction builds the transaction described by req and its journal entry
+func (s *transactionService) newTransaction(req *dto.TransactionRequest) (*entity.Transaction, *entity.JournalEntry, error) {
 	transaction := &entity.Transaction{
 		TransactionID:     req.TransactionID,
 		Value:             req.Value,
+		Currency:          req.Value.Currency(),
 		Type:              req.Type,
 		ExternalCompanyID: req.ExternalCompanyID,
 		Description:       req.Description,
 	}
 
-	if err := s.repo.Create(transaction); err != nil {
-		return nil, err
+	entry, err := s.rules.Entry(transaction)
+	if err != nil {
+		return nil, nil, entryError(err)
 	}
 
-	return s.entityToResponse(transaction), nil
+	return transaction, entry, nil
 }
 
-func (s *transactionService) GetTransaction(id int) (*dto.TransactionResponse, error) {
-	transaction, err := s.repo.GetByID(id)
+// entryError blames the request field behind a failure to build a journal entry. An entry
+// that does not balance is a bug in the posting rules, not in the request, so it is not a
+// validation error.
+func entryError(err error) error {
+	switch {
+	case errors.Is(err, ErrUnsupportedType):
+		return &ValidationError{Field: "type", Err: err}
+	case errors.Is(err, money.ErrOverflow):
+		return &ValidationError{Field: "value", Err: err}
+	default:
+		return err
+	}
+}
+
+// resolveDuplicate tells a repeated request, answered with the stored transaction and
+// ErrDuplicate, from a reuse of the transaction_id for different content
+func (s *transactionService) resolveDuplicate(duplicate *repository.DuplicateError, transaction *entity.Transaction) (*dto.TransactionResponse, error) {
+	if !sameContent(duplicate.Existing, transaction) {
+		return nil, fmt.Errorf("%w: %s", ErrConflict, transaction.TransactionID)
+	}
+	return s.entityToResponse(duplicate.Existing), fmt.Errorf("%w: %s", ErrDuplicate, transaction.TransactionID)
+}
+
+func (s *transactionService) GetTransaction(ctx context.Context, id int) (*dto.TransactionResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	transaction, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("transaction not found")
-		}
-		return nil, err
+		return nil, wrapRepoError(fmt.Sprintf("get transaction %d", id), err)
 	}
 
 	return s.entityToResponse(transaction), nil
 }
 
-func (s *transactionService) GetTransactionByID(transactionID string) (*dto.TransactionResponse, error) {
-	transaction, err := s.repo.GetByTransactionID(transactionID)
+func (s *transactionService) GetTransactionByID(ctx context.Context, transactionID string) (*dto.TransactionResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	transaction, err := s.repo.GetByTransactionID(ctx, transactionID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("transaction not found")
-		}
-		return nil, err
+		return nil, wrapRepoError("get transaction "+transactionID, err)
 	}
 
 	return s.entityToResponse(transaction), nil
 }
 
-func (s *transactionService) GetTransactionsByCompany(externalCompanyID string) ([]*dto.TransactionResponse, error) {
-	transactions, err := s.repo.GetByExternalCompanyID(externalCompanyID)
+func (s *transactionService) GetTransactionsByCompany(ctx context.Context, externalCompanyID string) ([]*dto.TransactionResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	transactions, err := s.repo.GetByExternalCompanyID(ctx, externalCompanyID)
 	if err != nil {
-		return nil, err
+		return nil, wrapRepoError("list transactions of company "+externalCompanyID, err)
 	}
 
 	var responses []*dto.TransactionResponse
@@ -88,7 +196,10 @@ func (s *transactionService) GetTransactionsByCompany(externalCompanyID string)
 	return responses, nil
 }
 
-func (s *transactionService) ListTransactions(limit, offset int) ([]*dto.TransactionResponse, error) {
+func (s *transactionService) ListTransactions(ctx context.Context, limit, offset int) ([]*dto.TransactionResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
 	if limit <= 0 {
 		limit = 10
 	}
@@ -99,9 +210,9 @@ func (s *transactionService) ListTransactions(limit, offset int) ([]*dto.Transac
 		offset = 0
 	}
 
-	transactions, err := s.repo.List(limit, offset)
+	transactions, err := s.repo.List(ctx, limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, wrapRepoError("list transactions", err)
 	}
 
 	var responses []*dto.TransactionResponse
@@ -112,48 +223,246 @@ func (s *transactionService) ListTransactions(limit, offset int) ([]*dto.Transac
 	return responses, nil
 }
 
-func (s *transactionService) UpdateTransaction(id int, req *dto.TransactionRequest) (*dto.TransactionResponse, error) {
-	existing, err := s.repo.GetByID(id)
+// SearchTransactions returns a page of the transactions matching the query, newest first,
+// with the cursor of the next page and the total number of matches
+func (s *transactionService) SearchTransactions(ctx context.Context, q *dto.TransactionQuery) (*dto.TransactionPage, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	query, err := parseQuery(q)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("transaction not found")
-		}
 		return nil, err
 	}
 
-	if existing.TransactionID != req.TransactionID {
-		duplicate, err := s.repo.GetByTransactionID(req.TransactionID)
-		if err != nil && err != sql.ErrNoRows {
-			return nil, err
-		}
-		if duplicate != nil {
-			return nil, errors.New("transaction with this ID already exists")
-		}
+	// Fetch one extra row to learn whether another page follows
+	limit := query.Limit
+	query.Limit++
+	transactions, err := s.repo.Search(ctx, query)
+	if err != nil {
+		return nil, wrapRepoError("search transactions", err)
+	}
+
+	total, err := s.repo.Count(ctx, query.TransactionFilter)
+	if err != nil {
+		return nil, wrapRepoError("count transactions", err)
+	}
+
+	page := &dto.TransactionPage{
+		Transactions: make([]*dto.TransactionResponse, 0, limit),
+		Total:        total,
+	}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		page.NextCursor = encodeCursor(transactions[limit-1])
+	}
+	for _, transaction := range transactions {
+		page.Transactions = append(page.Transactions, s.entityToResponse(transaction))
+	}
+
+	return page, nil
+}
+
+func (s *transactionService) UpdateTransaction(ctx context.Context, id int, req *dto.TransactionRequest) (*dto.TransactionResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, wrapRepoError(fmt.Sprintf("update transaction %d", id), err)
 	}
 
 	existing.TransactionID = req.TransactionID
 	existing.Value = req.Value
+	existing.Currency = req.Value.Currency()
 	existing.Type = req.Type
 	existing.ExternalCompanyID = req.ExternalCompanyID
 	existing.Description = req.Description
 
-	if err := s.repo.Update(existing); err != nil {
-		return nil, err
+	entry, err := s.rules.Entry(existing)
+	if err != nil {
+		return nil, entryError(err)
+	}
+
+	if err := s.repo.Update(ctx, existing, entry); err != nil {
+		return nil, wrapRepoError(fmt.Sprintf("update transaction %d", id), err)
 	}
 
 	return s.entityToResponse(existing), nil
 }
 
-func (s *transactionService) DeleteTransaction(id int) error {
-	_, err := s.repo.GetByID(id)
+func (s *transactionService) DeleteTransaction(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	op := fmt.Sprintf("delete transaction %d", id)
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return wrapRepoError(op, err)
+	}
+
+	return wrapRepoError(op, s.repo.Delete(ctx, id))
+}
+
+// GetBalance returns the company's current balance in the currency, DefaultCurrency if empty.
+// The balance is its customer ledger account, net of fees.
+func (s *transactionService) GetBalance(ctx context.Context, externalCompanyID, currency string) (*dto.BalanceResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	c, err := parseCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.balances.Get(ctx, externalCompanyID, c)
+	if err != nil {
+		return nil, wrapRepoError("get balance of company "+externalCompanyID, err)
+	}
+
+	return balanceToResponse(balance), nil
+}
+
+// GetBalanceAt returns the company's balance as it stood at the given instant
+func (s *transactionService) GetBalanceAt(ctx context.Context, externalCompanyID, currency string, at time.Time) (*dto.BalanceResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	c, err := parseCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.balances.GetAt(ctx, externalCompanyID, c, at)
+	if err != nil {
+		return nil, wrapRepoError("get balance of company "+externalCompanyID, err)
+	}
+
+	return balanceToResponse(balance), nil
+}
+
+// CheckBalances recomputes every stored balance from the customer account lines of the ledger
+// and reports the ones that drifted. Balances are net of fees, so they are not expected to
+// match the sum of the company's transactions.
+func (s *transactionService) CheckBalances(ctx context.Context) ([]*dto.BalanceDriftResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	drifts, err := s.balances.FindDrift(ctx)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return errors.New("transaction not found")
+		return nil, wrapRepoError("check balances", err)
+	}
+
+	responses := make([]*dto.BalanceDriftResponse, 0, len(drifts))
+	for _, drift := range drifts {
+		difference, err := drift.Stored.Subtract(drift.Computed)
+		if err != nil {
+			return nil, err
 		}
-		return err
+		responses = append(responses, &dto.BalanceDriftResponse{
+			ExternalCompanyID: drift.ExternalCompanyID,
+			Currency:          drift.Currency.String(),
+			Stored:            drift.Stored,
+			Computed:          drift.Computed,
+			Drift:             difference,
+		})
 	}
 
-	return s.repo.Delete(id)
+	return responses, nil
+}
+
+// GetJournal returns the journal entries posted for a transaction, reversals included
+func (s *transactionService) GetJournal(ctx context.Context, transactionID string) ([]*dto.JournalEntryResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	entries, err := s.ledger.GetEntriesByTransactionID(ctx, transactionID)
+	if err != nil {
+		return nil, wrapRepoError("get journal of transaction "+transactionID, err)
+	}
+
+	responses := make([]*dto.JournalEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response := &dto.JournalEntryResponse{
+			ID:            entry.ID,
+			TransactionID: entry.TransactionID,
+			Kind:          entry.Kind,
+			Description:   entry.Description,
+			CreatedAt:     entry.CreatedAt,
+		}
+		for _, line := range entry.Lines {
+			response.Lines = append(response.Lines, dto.JournalLineResponse{
+				AccountCode: line.AccountCode,
+				AccountType: line.AccountType,
+				Amount:      line.Amount,
+				Currency:    line.Amount.Currency().String(),
+			})
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+// GetAccountBalance returns the net debit balance of a ledger account
+func (s *transactionService) GetAccountBalance(ctx context.Context, code, currency string) (*dto.AccountBalanceResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	c, err := parseCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.ledger.GetAccountBalance(ctx, code, c)
+	if err != nil {
+		return nil, wrapRepoError("get balance of account "+code, err)
+	}
+
+	return &dto.AccountBalanceResponse{
+		AccountCode: code,
+		Currency:    c.String(),
+		Balance:     balance,
+	}, nil
+}
+
+// sameContent reports whether two transactions describe the same payment
+func sameContent(a, b *entity.Transaction) bool {
+	return a.TransactionID == b.TransactionID &&
+		a.Value.Equal(b.Value) &&
+		a.Type == b.Type &&
+		a.ExternalCompanyID == b.ExternalCompanyID &&
+		a.Description == b.Description
+}
+
+// withTimeout derives a context that expires after d, or only with its parent when d is zero
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+func parseCurrency(currency string) (money.Currency, error) {
+	if currency == "" {
+		return money.DefaultCurrency, nil
+	}
+	c, err := money.ParseCurrency(currency)
+	if err != nil {
+		return "", &ValidationError{Field: "currency", Err: err}
+	}
+	return c, nil
+}
+
+func balanceToResponse(balance *entity.Balance) *dto.BalanceResponse {
+	asOf := balance.UpdatedAt
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	return &dto.BalanceResponse{
+		ExternalCompanyID: balance.ExternalCompanyID,
+		Currency:          balance.Currency.String(),
+		Balance:           balance.Balance,
+		AsOf:              asOf,
+	}
 }
 
 func (s *transactionService) entityToResponse(transaction *entity.Transaction) *dto.TransactionResponse {
@@ -161,10 +470,11 @@ func (s *transactionService) entityToResponse(transaction *entity.Transaction) *
 		ID:                transaction.ID,
 		TransactionID:     transaction.TransactionID,
 		Value:             transaction.Value,
+		Currency:          transaction.Value.Currency().String(),
 		Type:              transaction.Type,
 		ExternalCompanyID: transaction.ExternalCompanyID,
 		Description:       transaction.Description,
 		CreatedAt:         transaction.CreatedAt,
 		UpdatedAt:         transaction.UpdatedAt,
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"register-payment/internal/entity"
+	"register-payment/internal/repository"
+	"register-payment/pkg/money"
+	"testing"
+	"time"
+)
+
+type contextKey struct{}
+
+// contextRepository records the context it is called with and fails like a driver once it is done
+type contextRepository struct {
+	repository.TransactionRepository
+	ctx context.Context
+}
+
+func (r *contextRepository) GetByID(ctx context.Context, id int) (*entity.Transaction, error) {
+	r.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &entity.Transaction{ID: id, TransactionID: "TXN-1", Value: money.NewMoneyFromCents(100)}, nil
+}
+
+func TestTransactionServicePassesContext(t *testing.T) {
+	repo := &contextRepository{}
+	transactionService := NewTransactionService(repo, nil, nil, PostingRules{}, Timeouts{Read: time.Minute})
+
+	ctx := context.WithValue(context.Background(), contextKey{}, "caller")
+	if _, err := transactionService.GetTransaction(ctx, 1); err != nil {
+		t.Fatalf("GetTransaction error: %v", err)
+	}
+	if repo.ctx.Value(contextKey{}) != "caller" {
+		t.Error("repository did not get a context derived from the caller's")
+	}
+	if _, ok := repo.ctx.Deadline(); !ok {
+		t.Error("repository context has no deadline despite a read timeout")
+	}
+	if repo.ctx.Err() == nil {
+		t.Error("repository context still live after the call returned")
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := transactionService.GetTransaction(canceled, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetTransaction with a canceled context = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_company_currency_created_at;
+DROP TABLE IF EXISTS balances;
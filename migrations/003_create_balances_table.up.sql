@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS balances (
+    external_company_id VARCHAR(255) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    balance BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (external_company_id, currency)
+);
+
+INSERT INTO balances (external_company_id, currency, balance, updated_at)
+SELECT external_company_id, currency, SUM(CASE WHEN type = 'in' THEN value ELSE -value END), NOW()
+FROM transactions
+GROUP BY external_company_id, currency
+ON CONFLICT (external_company_id, currency) DO NOTHING;
+
+CREATE INDEX idx_transactions_company_currency_created_at ON transactions(external_company_id, currency, created_at);
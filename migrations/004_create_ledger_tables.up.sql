@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS accounts (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(300) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('asset', 'liability', 'revenue', 'expense')),
+    external_company_id VARCHAR(255),
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (code, currency)
+);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id SERIAL PRIMARY KEY,
+    transaction_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('payment', 'reversal')),
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS journal_lines (
+    id SERIAL PRIMARY KEY,
+    journal_entry_id INTEGER NOT NULL REFERENCES journal_entries(id),
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    currency CHAR(3) NOT NULL
+);
+
+CREATE INDEX idx_journal_entries_transaction_id ON journal_entries(transaction_id);
+CREATE INDEX idx_journal_lines_journal_entry_id ON journal_lines(journal_entry_id);
+CREATE INDEX idx_journal_lines_account_id ON journal_lines(account_id);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS transaction_statuses (
+    transaction_id VARCHAR(255) PRIMARY KEY,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('queued', 'processing', 'registered', 'rejected', 'dead_lettered')),
+    reason TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO transaction_statuses (transaction_id, status, attempts, created_at, updated_at)
+SELECT transaction_id, 'registered', 1, created_at, updated_at
+FROM transactions
+ON CONFLICT (transaction_id) DO NOTHING;
+
+CREATE INDEX idx_transaction_statuses_status ON transaction_statuses(status);
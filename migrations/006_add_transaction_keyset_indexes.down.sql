@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_company_created_at_id;
+DROP INDEX IF EXISTS idx_transactions_created_at_id;
@@ -0,0 +1,2 @@
+CREATE INDEX idx_transactions_created_at_id ON transactions(created_at DESC, id DESC);
+CREATE INDEX idx_transactions_company_created_at_id ON transactions(external_company_id, created_at DESC, id DESC);
@@ -0,0 +1,2 @@
+-- Backfilled entries cannot be told apart from posted ones once their transactions were
+-- updated or deleted, so rolling back keeps them.
@@ -0,0 +1,31 @@
+-- Transactions stored before the ledger existed have no journal entry, so account balances
+-- left them out and updating or deleting them reversed nothing. They are posted here as
+-- payments without fee lines, since no fee was charged when they were registered.
+INSERT INTO accounts (code, type, external_company_id, currency, created_at)
+SELECT DISTINCT a.code, a.type, a.external_company_id, t.currency, NOW()
+FROM transactions t
+CROSS JOIN LATERAL (VALUES
+    ('clearing', 'asset', NULL),
+    ('customer:' || t.external_company_id, 'liability', t.external_company_id)
+) AS a(code, type, external_company_id)
+WHERE NOT EXISTS (SELECT 1 FROM journal_entries e WHERE e.transaction_id = t.transaction_id)
+ON CONFLICT (code, currency) DO NOTHING;
+
+-- An incoming payment debits clearing and credits the customer account; an outgoing one the reverse
+WITH missing AS (
+    SELECT transaction_id, value, currency, type, external_company_id, description, created_at
+    FROM transactions t
+    WHERE value <> 0
+      AND NOT EXISTS (SELECT 1 FROM journal_entries e WHERE e.transaction_id = t.transaction_id)
+), entries AS (
+    INSERT INTO journal_entries (transaction_id, kind, description, created_at)
+    SELECT transaction_id, 'payment', description, created_at
+    FROM missing
+    RETURNING id, transaction_id
+)
+INSERT INTO journal_lines (journal_entry_id, account_id, amount, currency)
+SELECT e.id, a.id, side.sign * CASE WHEN m.type = 'in' THEN m.value ELSE -m.value END, m.currency
+FROM entries e
+JOIN missing m ON m.transaction_id = e.transaction_id
+CROSS JOIN LATERAL (VALUES ('clearing', 1), ('customer:' || m.external_company_id, -1)) AS side(code, sign)
+JOIN accounts a ON a.code = side.code AND a.currency = m.currency;
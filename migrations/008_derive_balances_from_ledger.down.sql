@@ -0,0 +1,8 @@
+UPDATE balances SET balance = 0, updated_at = NOW();
+
+INSERT INTO balances (external_company_id, currency, balance, updated_at)
+SELECT external_company_id, currency, SUM(CASE WHEN type = 'in' THEN value ELSE -value END), NOW()
+FROM transactions
+GROUP BY external_company_id, currency
+ON CONFLICT (external_company_id, currency)
+DO UPDATE SET balance = EXCLUDED.balance, updated_at = EXCLUDED.updated_at;
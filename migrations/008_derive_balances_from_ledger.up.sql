@@ -0,0 +1,12 @@
+-- Balances become the customer ledger accounts, net of fees, instead of the sum of the
+-- transaction values. Every balance is rebuilt from the journal.
+UPDATE balances SET balance = 0, updated_at = NOW();
+
+INSERT INTO balances (external_company_id, currency, balance, updated_at)
+SELECT a.external_company_id, a.currency, -SUM(l.amount), NOW()
+FROM journal_lines l
+JOIN accounts a ON a.id = l.account_id
+WHERE a.external_company_id IS NOT NULL
+GROUP BY a.external_company_id, a.currency
+ON CONFLICT (external_company_id, currency)
+DO UPDATE SET balance = EXCLUDED.balance, updated_at = EXCLUDED.updated_at;
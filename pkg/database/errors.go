@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// transientCodes are PostgreSQL error codes, or code classes, worth retrying
+var transientCodes = []string{
+	"08",    // connection exception
+	"40001", // serialization failure
+	"40P01", // deadlock detected
+	"53",    // insufficient resources
+	"57P01", // admin shutdown
+	"57P02", // crash shutdown
+	"57P03", // cannot connect now
+}
+
+// IsTransient reports whether err is a temporary database failure, such as a lost
+// connection, deadlock or timeout, that may succeed if the operation is retried
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		for _, code := range transientCodes {
+			if strings.HasPrefix(string(pqErr.Code), code) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,71 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrInvalidRatio is returned when allocation ratios are missing, negative or all zero
+var ErrInvalidRatio = errors.New("invalid allocation ratio")
+
+// Allocate distributes the value proportionally to the given ratios.
+// Each share is rounded towards zero and the leftover minor units are handed out
+// one at a time to the first non-zero shares, so the parts always sum to the original value.
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("%w: no ratios given", ErrInvalidRatio)
+	}
+
+	var total int64
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, fmt.Errorf("%w: negative ratio %d", ErrInvalidRatio, ratio)
+		}
+		total += int64(ratio)
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("%w: ratios sum to zero", ErrInvalidRatio)
+	}
+
+	// amount * ratio can exceed int64, so shares are computed with big.Int;
+	// every share is bounded by amount and therefore fits back into int64
+	amount := big.NewInt(m.amount)
+	divisor := big.NewInt(total)
+	parts := make([]Money, len(ratios))
+	remainder := m.amount
+	for i, ratio := range ratios {
+		share := new(big.Int).Mul(amount, big.NewInt(int64(ratio)))
+		share.Quo(share, divisor)
+		parts[i] = Money{amount: share.Int64(), currency: m.Currency()}
+		remainder -= share.Int64()
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(parts) {
+		if ratios[i] == 0 {
+			continue
+		}
+		parts[i].amount += step
+		remainder -= step
+	}
+
+	return parts, nil
+}
+
+// Split divides the value into n parts that differ by at most one minor unit,
+// with the larger parts first, e.g. 100.00 split in 3 is 33.34, 33.33, 33.33
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: cannot split into %d parts", ErrDivisionByZero, n)
+	}
+
+	ratios := make([]int, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
@@ -0,0 +1,157 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Currency is an ISO 4217 alphabetic currency code such as "BRL" or "JPY"
+type Currency string
+
+// Commonly used currencies
+const (
+	BRL Currency = "BRL"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	JPY Currency = "JPY"
+	KWD Currency = "KWD"
+)
+
+// DefaultCurrency is assumed whenever a Money value carries no currency
+const DefaultCurrency = BRL
+
+var (
+	// ErrUnknownCurrency is returned when a currency code is not in the ISO 4217 table
+	ErrUnknownCurrency = errors.New("unknown currency")
+	// ErrCurrencyMismatch is returned by operations mixing two different currencies
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// CurrencyMismatchError reports an operation attempted between two currencies
+type CurrencyMismatchError struct {
+	Left  Currency
+	Right Currency
+}
+
+func (e *CurrencyMismatchError) Error() string {
+	return fmt.Sprintf("currency mismatch: %s and %s", e.Left, e.Right)
+}
+
+// Is makes errors.Is(err, ErrCurrencyMismatch) match a *CurrencyMismatchError
+func (e *CurrencyMismatchError) Is(target error) bool {
+	return target == ErrCurrencyMismatch
+}
+
+// minorUnits maps ISO 4217 codes to the number of decimal places of their minor unit
+var minorUnits = map[Currency]int{
+	"ARS": 2,
+	"AUD": 2,
+	"BHD": 3,
+	"BRL": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CLP": 0,
+	"CNY": 2,
+	"COP": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"IQD": 3,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"LYD": 3,
+	"MXN": 2,
+	"OMR": 3,
+	"PEN": 2,
+	"PYG": 0,
+	"TND": 3,
+	"USD": 2,
+	"UYU": 2,
+	"VND": 0,
+}
+
+// symbols maps currencies to the symbol commonly printed before or after amounts
+var symbols = map[Currency]string{
+	"BRL": "R$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"USD": "$",
+}
+
+// ParseCurrency normalizes a currency code and checks it against the ISO 4217 table
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := minorUnits[c]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+	return c, nil
+}
+
+// Code returns the currency code, resolving the empty currency to DefaultCurrency
+func (c Currency) Code() Currency {
+	if c == "" {
+		return DefaultCurrency
+	}
+	return c
+}
+
+// String returns the ISO 4217 code
+func (c Currency) String() string {
+	return string(c.Code())
+}
+
+// IsKnown returns true if the currency is in the ISO 4217 table
+func (c Currency) IsKnown() bool {
+	_, ok := minorUnits[c.Code()]
+	return ok
+}
+
+// Exponent returns the number of decimal places of the currency's minor unit.
+// Unknown currencies are treated as having two decimal places.
+func (c Currency) Exponent() int {
+	if exp, ok := minorUnits[c.Code()]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Symbol returns the currency symbol, falling back to the ISO code when there is none
+func (c Currency) Symbol() string {
+	if symbol, ok := symbols[c.Code()]; ok {
+		return symbol
+	}
+	return c.String()
+}
+
+// Value implements driver.Valuer interface for database storage
+func (c Currency) Value() (driver.Value, error) {
+	return c.String(), nil
+}
+
+// Scan implements sql.Scanner interface for database retrieval
+func (c *Currency) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = DefaultCurrency
+	case string:
+		*c = Currency(strings.TrimSpace(v))
+	case []byte:
+		*c = Currency(strings.TrimSpace(string(v)))
+	default:
+		return fmt.Errorf("cannot scan %T into Currency", value)
+	}
+	return nil
+}
+
+// pow10 returns 10^exp for the small exponents used by ISO 4217
+func pow10(exp int) int64 {
+	p := int64(1)
+	for i := 0; i < exp; i++ {
+		p *= 10
+	}
+	return p
+}
@@ -0,0 +1,155 @@
+package money
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Display selects how a Formatter shows the currency
+type Display int
+
+const (
+	// DisplaySymbol shows the currency symbol, e.g. "R$ 1.234,56"
+	DisplaySymbol Display = iota
+	// DisplayCode shows the ISO 4217 code, e.g. "BRL 1.234,56"
+	DisplayCode
+	// DisplayNone shows the number only, e.g. "1.234,56"
+	DisplayNone
+)
+
+// NegativeStyle selects how a Formatter writes negative amounts
+type NegativeStyle int
+
+const (
+	// NegativeMinus prefixes negative amounts with a minus sign, e.g. "-$1,234.56"
+	NegativeMinus NegativeStyle = iota
+	// NegativeParentheses wraps negative amounts in parentheses, accounting style, e.g. "($1,234.56)"
+	NegativeParentheses
+)
+
+// Formatter writes amounts following a locale's conventions and reads them back
+type Formatter struct {
+	Locale   Locale
+	Display  Display
+	Negative NegativeStyle
+	// Rounding is used by Parse for fractional digits beyond the currency's exponent.
+	// The zero value, RoundUnnecessary, rejects them.
+	Rounding RoundingMode
+}
+
+// NewFormatter returns a Formatter for the locale showing currency symbols and minus signs
+func NewFormatter(locale Locale) Formatter {
+	return Formatter{Locale: locale}
+}
+
+// Format returns the amount written in the formatter's locale, e.g. "R$ 1.234,56" for pt-BR
+func (f Formatter) Format(m Money) string {
+	body := f.formatNumber(m)
+
+	if label := f.label(m.Currency()); label != "" {
+		separator := ""
+		if f.Locale.SymbolSpace || label == m.Currency().String() {
+			separator = " "
+		}
+		if f.Locale.SymbolAfter {
+			body = body + separator + label
+		} else {
+			body = label + separator + body
+		}
+	}
+
+	if !m.IsNegative() {
+		return body
+	}
+	if f.Negative == NegativeParentheses {
+		return "(" + body + ")"
+	}
+	return "-" + body
+}
+
+// Parse reads an amount written by Format, or by hand in the same locale, into the given currency.
+// The currency symbol or code is optional and may appear before or after the number; negative
+// amounts may use a minus sign on either side of the symbol or accounting parentheses.
+func (f Formatter) Parse(s string, currency Currency) (Money, error) {
+	original := s
+	s = strings.TrimSpace(s)
+
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	if strings.HasPrefix(s, "-") {
+		if negative {
+			return Money{}, fmt.Errorf("%w: %q has two negative signs", ErrInvalidFormat, original)
+		}
+		negative = true
+		s = strings.TrimSpace(s[1:])
+	}
+
+	for _, label := range []string{currency.Symbol(), currency.String()} {
+		if strings.HasPrefix(s, label) {
+			s = strings.TrimSpace(strings.TrimPrefix(s, label))
+			break
+		}
+		if strings.HasSuffix(s, label) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, label))
+			break
+		}
+	}
+
+	if negative {
+		if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+			return Money{}, fmt.Errorf("%w: %q has two signs", ErrInvalidFormat, original)
+		}
+		s = "-" + s
+	}
+
+	return Parse(s, currency, ParseOptions{Locale: f.Locale, Rounding: f.Rounding})
+}
+
+// Format returns the amount written in the locale with the currency symbol
+func (m Money) Format(locale Locale) string {
+	return NewFormatter(locale).Format(m)
+}
+
+// label returns the currency label to print according to the Display setting
+func (f Formatter) label(currency Currency) string {
+	switch f.Display {
+	case DisplaySymbol:
+		return currency.Symbol()
+	case DisplayCode:
+		return currency.String()
+	default:
+		return ""
+	}
+}
+
+// formatNumber writes the absolute amount with the locale's separators
+func (f Formatter) formatNumber(m Money) string {
+	magnitude := uint64(m.amount)
+	if m.amount < 0 {
+		magnitude = uint64(-m.amount)
+	}
+
+	exp := m.Currency().Exponent()
+	digits := strconv.FormatUint(magnitude, 10)
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	intPart, fracPart := digits[:len(digits)-exp], digits[len(digits)-exp:]
+
+	var b strings.Builder
+	for i, d := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 && f.Locale.GroupSeparator != 0 {
+			b.WriteRune(f.Locale.GroupSeparator)
+		}
+		b.WriteRune(d)
+	}
+	if exp > 0 {
+		b.WriteRune(f.Locale.decimalSeparator())
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
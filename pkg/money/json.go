@@ -0,0 +1,279 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONFormat selects the shape MarshalJSON writes. Every shape is accepted by UnmarshalJSON.
+// Values are written as strings unless WithJSONFormat or ApplyJSONFormat picks another shape.
+type JSONFormat int32
+
+const (
+	// JSONFormatString writes a decimal string, e.g. "150.75"
+	JSONFormatString JSONFormat = iota
+	// JSONFormatNumber writes a decimal number literal, e.g. 150.75
+	JSONFormatNumber
+	// JSONFormatCents writes integer minor units with the currency, e.g. {"amount_cents":15075,"currency":"BRL"}
+	JSONFormatCents
+)
+
+// maxJSONExponent bounds the exponent of numeric literals; anything larger cannot fit in int64 minor units
+const maxJSONExponent = 64
+
+var jsonFormatNames = map[JSONFormat]string{
+	JSONFormatString: "string",
+	JSONFormatNumber: "number",
+	JSONFormatCents:  "cents",
+}
+
+// String returns the name of the JSON format
+func (f JSONFormat) String() string {
+	if name, ok := jsonFormatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("JSONFormat(%d)", int32(f))
+}
+
+// ParseJSONFormat returns the JSON format with the given name: "string", "number" or "cents"
+func ParseJSONFormat(name string) (JSONFormat, error) {
+	for format, n := range jsonFormatNames {
+		if strings.EqualFold(n, strings.TrimSpace(name)) {
+			return format, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown money JSON format %q", name)
+}
+
+// WithJSONFormat returns a copy of the value that MarshalJSON writes in the given format
+func (m Money) WithJSONFormat(format JSONFormat) Money {
+	m.jsonFormat = format
+	return m
+}
+
+var moneyType = reflect.TypeOf(Money{})
+
+// ApplyJSONFormat returns a copy of v in which every Money value reachable through
+// exported fields, pointers, slices, arrays, maps and interfaces is written in the
+// given format. v itself is not modified.
+func ApplyJSONFormat(v interface{}, format JSONFormat) interface{} {
+	if v == nil {
+		return nil
+	}
+	return applyJSONFormat(reflect.ValueOf(v), format).Interface()
+}
+
+func applyJSONFormat(v reflect.Value, format JSONFormat) reflect.Value {
+	if v.Type() == moneyType {
+		return reflect.ValueOf(v.Interface().(Money).WithJSONFormat(format))
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(applyJSONFormat(v.Elem(), format))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(applyJSONFormat(v.Elem(), format))
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if field := out.Field(i); field.CanSet() {
+				field.Set(applyJSONFormat(v.Field(i), format))
+			}
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(applyJSONFormat(v.Index(i), format))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(applyJSONFormat(v.Index(i), format))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), applyJSONFormat(iter.Value(), format))
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// jsonObject is the explicit object form of a money value
+type jsonObject struct {
+	AmountCents *int64          `json:"amount_cents,omitempty"`
+	Amount      json.RawMessage `json:"amount,omitempty"`
+	Currency    string          `json:"currency,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler interface, writing a decimal string unless
+// WithJSONFormat selected another format
+func (m Money) MarshalJSON() ([]byte, error) {
+	return m.MarshalJSONAs(m.jsonFormat)
+}
+
+// MarshalJSONAs encodes the value in the given JSON format
+func (m Money) MarshalJSONAs(format JSONFormat) ([]byte, error) {
+	switch format {
+	case JSONFormatString:
+		return json.Marshal(m.String())
+	case JSONFormatNumber:
+		return []byte(m.String()), nil
+	case JSONFormatCents:
+		cents := m.amount
+		return json.Marshal(jsonObject{AmountCents: &cents, Currency: m.Currency().String()})
+	default:
+		return nil, fmt.Errorf("unsupported money JSON format %s", format)
+	}
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+// Strings and number literals are read in the receiver's currency, or DefaultCurrency if it has none.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+
+	money, err := ParseJSON(data, m.Currency())
+	if err != nil {
+		return err
+	}
+
+	*m = money
+	return nil
+}
+
+// ParseJSON decodes a JSON money value. It accepts a decimal string ("150.75"), a number
+// literal (150.75, parsed exactly from its text) or an object with "amount_cents" and/or
+// "amount" plus an optional "currency" that takes precedence over the given currency.
+func ParseJSON(data []byte, currency Currency) (Money, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return Money{}, fmt.Errorf("%w: empty JSON value", ErrInvalidFormat)
+	}
+
+	switch data[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return Money{}, err
+		}
+		return NewMoneyFromStringInCurrency(s, currency)
+	case '{':
+		return parseJSONObject(data, currency)
+	default:
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return Money{}, fmt.Errorf("%w: %s", ErrInvalidFormat, data)
+		}
+		decimal, err := expandExponent(number.String())
+		if err != nil {
+			return Money{}, err
+		}
+		return NewMoneyFromStringInCurrency(decimal, currency)
+	}
+}
+
+func parseJSONObject(data []byte, currency Currency) (Money, error) {
+	var obj jsonObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return Money{}, err
+	}
+
+	if obj.Currency != "" {
+		parsed, err := ParseCurrency(obj.Currency)
+		if err != nil {
+			return Money{}, err
+		}
+		currency = parsed
+	}
+
+	var fromAmount *Money
+	if len(obj.Amount) > 0 {
+		if obj.Amount[0] == '{' {
+			return Money{}, fmt.Errorf("%w: nested money object", ErrInvalidFormat)
+		}
+		m, err := ParseJSON(obj.Amount, currency)
+		if err != nil {
+			return Money{}, err
+		}
+		fromAmount = &m
+	}
+
+	switch {
+	case obj.AmountCents != nil && fromAmount != nil:
+		if fromAmount.amount != *obj.AmountCents {
+			return Money{}, fmt.Errorf("%w: amount %s does not match amount_cents %d", ErrInvalidFormat, fromAmount, *obj.AmountCents)
+		}
+		return *fromAmount, nil
+	case obj.AmountCents != nil:
+		return NewMoneyFromMinorUnits(*obj.AmountCents, currency), nil
+	case fromAmount != nil:
+		return *fromAmount, nil
+	default:
+		return Money{}, fmt.Errorf("%w: object needs amount_cents or amount", ErrInvalidFormat)
+	}
+}
+
+// expandExponent rewrites a JSON number such as "1.5e2" as a plain decimal "150"
+// so it can be parsed exactly
+func expandExponent(number string) (string, error) {
+	i := strings.IndexAny(number, "eE")
+	if i < 0 {
+		return number, nil
+	}
+
+	mantissa, exponent := number[:i], number[i+1:]
+	exp, err := strconv.Atoi(exponent)
+	if err != nil || exp > maxJSONExponent || exp < -maxJSONExponent {
+		return "", fmt.Errorf("%w: exponent out of range in %s", ErrInvalidFormat, number)
+	}
+
+	sign := ""
+	if strings.HasPrefix(mantissa, "-") {
+		sign, mantissa = "-", mantissa[1:]
+	}
+
+	intPart, fracPart := mantissa, ""
+	if j := strings.IndexByte(mantissa, '.'); j >= 0 {
+		intPart, fracPart = mantissa[:j], mantissa[j+1:]
+	}
+
+	digits := intPart + fracPart
+	point := len(intPart) + exp
+	switch {
+	case point <= 0:
+		return sign + "0." + strings.Repeat("0", -point) + digits, nil
+	case point >= len(digits):
+		return sign + digits + strings.Repeat("0", point-len(digits)), nil
+	default:
+		return sign + digits[:point] + "." + digits[point:], nil
+	}
+}
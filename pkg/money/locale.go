@@ -0,0 +1,61 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Locale describes how amounts are written in a region
+type Locale struct {
+	Name             string
+	DecimalSeparator rune
+	GroupSeparator   rune
+	// SymbolAfter places the currency symbol after the number instead of before it
+	SymbolAfter bool
+	// SymbolSpace separates the currency symbol from the number with a space
+	SymbolSpace bool
+}
+
+// Supported locales
+var (
+	LocaleEnUS = Locale{Name: "en-US", DecimalSeparator: '.', GroupSeparator: ','}
+	LocalePtBR = Locale{Name: "pt-BR", DecimalSeparator: ',', GroupSeparator: '.', SymbolSpace: true}
+	LocaleDeDE = Locale{Name: "de-DE", DecimalSeparator: ',', GroupSeparator: '.', SymbolAfter: true, SymbolSpace: true}
+	LocaleFrFR = Locale{Name: "fr-FR", DecimalSeparator: ',', GroupSeparator: '\u202f', SymbolAfter: true, SymbolSpace: true}
+	LocaleJaJP = Locale{Name: "ja-JP", DecimalSeparator: '.', GroupSeparator: ','}
+)
+
+var locales = []Locale{LocaleEnUS, LocalePtBR, LocaleDeDE, LocaleFrFR, LocaleJaJP}
+
+// ParseLocale returns the supported locale with the given BCP 47 name, e.g. "pt-BR" or "pt_br"
+func ParseLocale(name string) (Locale, error) {
+	normalized := strings.ReplaceAll(strings.TrimSpace(name), "_", "-")
+	for _, locale := range locales {
+		if strings.EqualFold(locale.Name, normalized) {
+			return locale, nil
+		}
+	}
+	return Locale{}, fmt.Errorf("unsupported locale %q", name)
+}
+
+// decimalSeparator returns the locale's decimal separator, '.' when unset
+func (l Locale) decimalSeparator() rune {
+	if l.DecimalSeparator == 0 {
+		return '.'
+	}
+	return l.DecimalSeparator
+}
+
+// isGroupSeparator reports whether r separates digit groups in this locale.
+// Locales grouping with a space accept any kind of space, since regular,
+// no-break and narrow no-break spaces are used interchangeably.
+func (l Locale) isGroupSeparator(r rune) bool {
+	if l.GroupSeparator == 0 {
+		return false
+	}
+	if unicode.IsSpace(l.GroupSeparator) {
+		return unicode.IsSpace(r)
+	}
+	return r == l.GroupSeparator
+}
@@ -2,169 +2,269 @@ package money
 
 import (
 	"database/sql/driver"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"strconv"
-	"strings"
+)
+
+var (
+	// ErrOverflow is returned when a value does not fit in int64 minor units
+	ErrOverflow = errors.New("money overflow")
+	// ErrDivisionByZero is returned when dividing or allocating by zero
+	ErrDivisionByZero = errors.New("money division by zero")
 )
 
 // Money represents monetary values using integers to avoid floating-point precision issues
-// Stores values in cents (smallest currency unit) as int64
+// Stores values in the currency's minor unit (cents for BRL, yen for JPY, fils for KWD) as int64
 type Money struct {
-	cents int64
+	amount   int64
+	currency Currency
+	// jsonFormat is the shape MarshalJSON writes; it does not take part in Equal or arithmetic
+	jsonFormat JSONFormat
 }
 
-// NewMoney creates a new Money instance from a floating-point value (dollars)
+// NewMoney creates a new Money instance in DefaultCurrency from a floating-point value (major units)
 func NewMoney(amount float64) Money {
-	return Money{cents: int64(math.Round(amount * 100))}
+	return NewMoneyInCurrency(amount, DefaultCurrency)
+}
+
+// NewMoneyInCurrency creates a new Money instance from a floating-point value in the given currency
+func NewMoneyInCurrency(amount float64, currency Currency) Money {
+	scale := float64(pow10(currency.Exponent()))
+	return Money{amount: int64(math.Round(amount * scale)), currency: currency.Code()}
 }
 
-// NewMoneyFromCents creates a new Money instance directly from cents
+// NewMoneyFromCents creates a new Money instance in DefaultCurrency directly from cents
 func NewMoneyFromCents(cents int64) Money {
-	return Money{cents: cents}
+	return Money{amount: cents, currency: DefaultCurrency}
 }
 
-// NewMoneyFromString creates a new Money instance from a string representation
-func NewMoneyFromString(s string) (Money, error) {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return Money{}, errors.New("empty string")
-	}
+// NewMoneyFromMinorUnits creates a new Money instance from an amount in the currency's minor unit
+func NewMoneyFromMinorUnits(amount int64, currency Currency) Money {
+	return Money{amount: amount, currency: currency.Code()}
+}
 
-	amount, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return Money{}, fmt.Errorf("invalid money format: %w", err)
-	}
+// NewMoneyFromString creates a new Money instance in DefaultCurrency from a string representation
+func NewMoneyFromString(s string) (Money, error) {
+	return NewMoneyFromStringInCurrency(s, DefaultCurrency)
+}
 
-	return NewMoney(amount), nil
+// NewMoneyFromStringInCurrency creates a new Money instance from a plain decimal string in the given currency.
+// More fractional digits than the currency allows are rejected; use Parse to round them instead.
+func NewMoneyFromStringInCurrency(s string, currency Currency) (Money, error) {
+	return Parse(s, currency, ParseOptions{})
 }
 
-// Cents returns the value in cents
+// Cents returns the value in the currency's minor unit
 func (m Money) Cents() int64 {
-	return m.cents
+	return m.amount
 }
 
-// Float64 returns the value as a float64 (dollars)
+// Currency returns the currency of the value
+func (m Money) Currency() Currency {
+	return m.currency.Code()
+}
+
+// Float64 returns the value as a float64 (major units)
 func (m Money) Float64() float64 {
-	return float64(m.cents) / 100.0
+	return float64(m.amount) / float64(pow10(m.Currency().Exponent()))
 }
 
-// String returns a string representation of the money value
+// String returns a string representation of the money value using the currency's decimal places
 func (m Money) String() string {
-	dollars := m.cents / 100
-	cents := m.cents % 100
-	if cents < 0 {
-		cents = -cents
+	exp := m.Currency().Exponent()
+	if exp == 0 {
+		return strconv.FormatInt(m.amount, 10)
+	}
+
+	scale := pow10(exp)
+	major := m.amount / scale
+	minor := m.amount % scale
+	sign := ""
+	if m.amount < 0 {
+		sign = "-"
+		major = -major
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, major, exp, minor)
+}
+
+// sameCurrency returns a *CurrencyMismatchError if the two values have different currencies
+func (m Money) sameCurrency(other Money) error {
+	if m.Currency() != other.Currency() {
+		return &CurrencyMismatchError{Left: m.Currency(), Right: other.Currency()}
 	}
-	return fmt.Sprintf("%d.%02d", dollars, cents)
+	return nil
 }
 
 // Add adds another Money value to this one
-func (m Money) Add(other Money) Money {
-	return Money{cents: m.cents + other.cents}
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	sum, ok := addInt64(m.amount, other.amount)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, other)
+	}
+	return Money{amount: sum, currency: m.Currency()}, nil
 }
 
 // Subtract subtracts another Money value from this one
-func (m Money) Subtract(other Money) Money {
-	return Money{cents: m.cents - other.cents}
+func (m Money) Subtract(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	difference, ok := subInt64(m.amount, other.amount)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m, other)
+	}
+	return Money{amount: difference, currency: m.Currency()}, nil
 }
 
-// Multiply multiplies the money value by a factor
+// Multiply multiplies the money value by a factor using float64 math and half away from zero rounding.
+// Prefer MultiplyRat or ApplyBasisPoints when the rounding mode matters.
 func (m Money) Multiply(factor float64) Money {
-	return Money{cents: int64(math.Round(float64(m.cents) * factor))}
+	return Money{amount: int64(math.Round(float64(m.amount) * factor)), currency: m.Currency()}
 }
 
-// Divide divides the money value by a divisor
+// Divide divides the money value by a divisor using float64 math and half away from zero rounding.
+// Prefer DivideRat when the rounding mode matters.
 func (m Money) Divide(divisor float64) Money {
 	if divisor == 0 {
-		return Money{cents: 0}
+		return Money{amount: 0, currency: m.Currency()}
 	}
-	return Money{cents: int64(math.Round(float64(m.cents) / divisor))}
+	return Money{amount: int64(math.Round(float64(m.amount) / divisor)), currency: m.Currency()}
+}
+
+// CheckedMultiply multiplies the money value by an integer factor, failing instead of wrapping on overflow
+func (m Money) CheckedMultiply(factor int64) (Money, error) {
+	product, ok := mulInt64(m.amount, factor)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrOverflow, m, factor)
+	}
+	return Money{amount: product, currency: m.Currency()}, nil
+}
+
+// CheckedDivide divides the money value by an integer divisor, rounding half away from zero like Divide.
+// A zero divisor returns ErrDivisionByZero instead of zero; use DivideRat to pick another rounding mode.
+func (m Money) CheckedDivide(divisor int64) (Money, error) {
+	return m.mulDiv(big.NewInt(1), big.NewInt(divisor), RoundHalfUp)
 }
 
 // IsZero returns true if the money value is zero
 func (m Money) IsZero() bool {
-	return m.cents == 0
+	return m.amount == 0
 }
 
 // IsPositive returns true if the money value is positive
 func (m Money) IsPositive() bool {
-	return m.cents > 0
+	return m.amount > 0
 }
 
 // IsNegative returns true if the money value is negative
 func (m Money) IsNegative() bool {
-	return m.cents < 0
+	return m.amount < 0
 }
 
 // Abs returns the absolute value of the money
 func (m Money) Abs() Money {
-	if m.cents < 0 {
-		return Money{cents: -m.cents}
+	if m.amount < 0 {
+		return Money{amount: -m.amount, currency: m.Currency()}
 	}
 	return m
 }
 
-// Equal checks if two Money values are equal
+// Negate returns the value with its sign flipped.
+// Negating the minimum int64 amount wraps; use CheckedMultiply(-1) to detect it.
+func (m Money) Negate() Money {
+	return Money{amount: -m.amount, currency: m.Currency()}
+}
+
+// Equal checks if two Money values have the same amount and currency
 func (m Money) Equal(other Money) bool {
-	return m.cents == other.cents
+	return m.Currency() == other.Currency() && m.amount == other.amount
+}
+
+// Compare returns -1, 0 or 1 depending on whether m is less than, equal to or greater than other
+func (m Money) Compare(other Money) (int, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.amount < other.amount:
+		return -1, nil
+	case m.amount > other.amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
 }
 
-// GreaterThan checks if this Money is greater than another
+// GreaterThan checks if this Money is greater than another.
+// Values in different currencies are never ordered; use Compare to get the error.
 func (m Money) GreaterThan(other Money) bool {
-	return m.cents > other.cents
+	cmp, err := m.Compare(other)
+	return err == nil && cmp > 0
 }
 
-// LessThan checks if this Money is less than another
+// LessThan checks if this Money is less than another.
+// Values in different currencies are never ordered; use Compare to get the error.
 func (m Money) LessThan(other Money) bool {
-	return m.cents < other.cents
+	cmp, err := m.Compare(other)
+	return err == nil && cmp < 0
 }
 
-// MarshalJSON implements json.Marshaler interface
-func (m Money) MarshalJSON() ([]byte, error) {
-	return json.Marshal(m.String())
+// addInt64 returns a + b and whether the sum fits in int64
+func addInt64(a, b int64) (int64, bool) {
+	sum := a + b
+	return sum, (sum > a) == (b > 0)
 }
 
-// UnmarshalJSON implements json.Unmarshaler interface
-func (m *Money) UnmarshalJSON(data []byte) error {
-	var s string
-	if err := json.Unmarshal(data, &s); err != nil {
-		return err
-	}
+// subInt64 returns a - b and whether the difference fits in int64
+func subInt64(a, b int64) (int64, bool) {
+	difference := a - b
+	return difference, (difference < a) == (b > 0)
+}
 
-	money, err := NewMoneyFromString(s)
-	if err != nil {
-		return err
+// mulInt64 returns a * b and whether the product fits in int64
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
 	}
-
-	*m = money
-	return nil
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
 }
 
 // Value implements driver.Valuer interface for database storage
 func (m Money) Value() (driver.Value, error) {
-	return m.cents, nil
+	return m.amount, nil
 }
 
-// Scan implements sql.Scanner interface for database retrieval
+// Scan implements sql.Scanner interface for database retrieval.
+// Only the amount is stored; the currency is kept from the receiver.
 func (m *Money) Scan(value interface{}) error {
+	m.currency = m.Currency()
+
 	if value == nil {
-		m.cents = 0
+		m.amount = 0
 		return nil
 	}
 
 	switch v := value.(type) {
 	case int64:
-		m.cents = v
+		m.amount = v
 	case int:
-		m.cents = int64(v)
+		m.amount = int64(v)
 	case float64:
-		m.cents = int64(math.Round(v))
+		m.amount = int64(math.Round(v))
 	default:
 		return fmt.Errorf("cannot scan %T into Money", value)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -2,6 +2,9 @@ package money
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
 	"testing"
 )
 
@@ -56,14 +59,20 @@ func TestMoneyOperations(t *testing.T) {
 	m2 := NewMoney(50.25)
 
 	// Addition
-	result := m1.Add(m2)
+	result, err := m1.Add(m2)
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
 	expected := NewMoney(150.75)
 	if !result.Equal(expected) {
 		t.Errorf("Add failed: %s + %s = %s, want %s", m1.String(), m2.String(), result.String(), expected.String())
 	}
 
 	// Subtraction
-	result = m1.Subtract(m2)
+	result, err = m1.Subtract(m2)
+	if err != nil {
+		t.Fatalf("Subtract returned error: %v", err)
+	}
 	expected = NewMoney(50.25)
 	if !result.Equal(expected) {
 		t.Errorf("Subtract failed: %s - %s = %s, want %s", m1.String(), m2.String(), result.String(), expected.String())
@@ -140,4 +149,524 @@ func TestMoneyComparisons(t *testing.T) {
 	if !zero.IsZero() {
 		t.Error("IsZero check failed")
 	}
-}
\ No newline at end of file
+}
+
+func TestMoneyCurrencyExponent(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		currency Currency
+		minor    int64
+		expected string
+	}{
+		{"BRL two decimals", "1234.56", BRL, 123456, "1234.56"},
+		{"USD negative", "-0.05", USD, -5, "-0.05"},
+		{"JPY no decimals", "1234", JPY, 1234, "1234"},
+		{"KWD three decimals", "1.234", KWD, 1234, "1.234"},
+		{"KWD negative", "-0.001", KWD, -1, "-0.001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := NewMoneyFromStringInCurrency(tt.input, tt.currency)
+			if err != nil {
+				t.Fatalf("NewMoneyFromStringInCurrency(%q, %s) error: %v", tt.input, tt.currency, err)
+			}
+			if money.Cents() != tt.minor {
+				t.Errorf("minor units = %d, want %d", money.Cents(), tt.minor)
+			}
+			if money.Currency() != tt.currency {
+				t.Errorf("currency = %s, want %s", money.Currency(), tt.currency)
+			}
+			if money.String() != tt.expected {
+				t.Errorf("String() = %s, want %s", money.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestMoneyCurrencyMismatch(t *testing.T) {
+	brl := NewMoneyFromMinorUnits(100, BRL)
+	usd := NewMoneyFromMinorUnits(100, USD)
+
+	_, err := brl.Add(usd)
+	if !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("Add across currencies error = %v, want ErrCurrencyMismatch", err)
+	}
+
+	var mismatch *CurrencyMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Left != BRL || mismatch.Right != USD {
+		t.Errorf("errors.As = %+v, want Left=BRL Right=USD", mismatch)
+	}
+
+	if _, err := brl.Subtract(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Subtract across currencies error = %v, want ErrCurrencyMismatch", err)
+	}
+
+	if brl.Equal(usd) {
+		t.Error("values in different currencies must not be equal")
+	}
+
+	if NewMoneyFromCents(100).Equal(Money{amount: 100}) == false {
+		t.Error("zero currency must resolve to DefaultCurrency")
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	c, err := ParseCurrency(" jpy ")
+	if err != nil || c != JPY {
+		t.Errorf("ParseCurrency(jpy) = %s, %v, want JPY", c, err)
+	}
+
+	if _, err := ParseCurrency("XXX1"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("ParseCurrency(XXX1) error = %v, want ErrUnknownCurrency", err)
+	}
+}
+
+func TestParseJSONInCurrency(t *testing.T) {
+	money, err := ParseJSON([]byte(`"12.345"`), KWD)
+	if err != nil {
+		t.Fatalf("ParseJSON error: %v", err)
+	}
+	if money.Cents() != 12345 || money.Currency() != KWD {
+		t.Errorf("ParseJSON = %d %s, want 12345 KWD", money.Cents(), money.Currency())
+	}
+
+	data, err := json.Marshal(NewMoneyFromMinorUnits(500, JPY))
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if string(data) != `"500"` {
+		t.Errorf("Marshal JPY = %s, want \"500\"", data)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		currency Currency
+		opts     ParseOptions
+		expected int64
+	}{
+		{"plain", "123.45", BRL, ParseOptions{}, 12345},
+		{"explicit plus", "+1.5", BRL, ParseOptions{}, 150},
+		{"leading fraction", ".05", USD, ParseOptions{}, 5},
+		{"en-US grouping", "-1,234,567.89", USD, ParseOptions{Locale: LocaleEnUS}, -123456789},
+		{"pt-BR grouping", "1.234,56", BRL, ParseOptions{Locale: LocalePtBR}, 123456},
+		{"fr-FR no-break space", "1\u00a0234,56", EUR, ParseOptions{Locale: LocaleFrFR}, 123456},
+		{"beyond float precision", "92233720368547758.07", BRL, ParseOptions{}, 9223372036854775807},
+		{"min int64", "-92233720368547758.08", BRL, ParseOptions{}, -9223372036854775808},
+		{"half up", "0.005", BRL, ParseOptions{Rounding: RoundHalfUp}, 1},
+		{"half even down", "0.005", BRL, ParseOptions{Rounding: RoundHalfEven}, 0},
+		{"half even up", "0.015", BRL, ParseOptions{Rounding: RoundHalfEven}, 2},
+		{"half even above half", "0.0051", BRL, ParseOptions{Rounding: RoundHalfEven}, 1},
+		{"down", "-1.999", BRL, ParseOptions{Rounding: RoundDown}, -199},
+		{"floor", "-1.991", BRL, ParseOptions{Rounding: RoundFloor}, -200},
+		{"ceiling", "1.991", BRL, ParseOptions{Rounding: RoundCeiling}, 200},
+		{"trailing zeros are exact", "1.2300", BRL, ParseOptions{}, 123},
+		{"JPY rounding", "1234.5", JPY, ParseOptions{Rounding: RoundHalfUp}, 1235},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := Parse(tt.input, tt.currency, tt.opts)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+			if money.Cents() != tt.expected {
+				t.Errorf("Parse(%q) = %d, want %d", tt.input, money.Cents(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		currency Currency
+		opts     ParseOptions
+		expected error
+	}{
+		{"empty", "  ", BRL, ParseOptions{}, ErrInvalidFormat},
+		{"letters", "12a.00", BRL, ParseOptions{}, ErrInvalidFormat},
+		{"sign only", "-", BRL, ParseOptions{}, ErrInvalidFormat},
+		{"double decimal", "1.2.3", BRL, ParseOptions{}, ErrInvalidFormat},
+		{"grouping not enabled", "1,234.56", BRL, ParseOptions{}, ErrInvalidFormat},
+		{"misplaced group", "12,34.56", USD, ParseOptions{Locale: LocaleEnUS}, ErrInvalidFormat},
+		{"trailing group", "1,234,.56", USD, ParseOptions{Locale: LocaleEnUS}, ErrInvalidFormat},
+		{"excess digits", "0.005", BRL, ParseOptions{}, ErrRoundingNecessary},
+		{"JPY fraction", "1.5", JPY, ParseOptions{}, ErrRoundingNecessary},
+		{"overflow", "92233720368547758.08", BRL, ParseOptions{}, ErrOverflow},
+		{"overflow by rounding", "92233720368547758.079", BRL, ParseOptions{Rounding: RoundCeiling}, ErrOverflow},
+		{"huge", "123456789012345678901234567890", BRL, ParseOptions{}, ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input, tt.currency, tt.opts)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.input, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckedArithmeticOverflow(t *testing.T) {
+	max := NewMoneyFromCents(math.MaxInt64)
+	min := NewMoneyFromCents(math.MinInt64)
+	one := NewMoneyFromCents(1)
+
+	if _, err := max.Add(one); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MaxInt64 + 1 error = %v, want ErrOverflow", err)
+	}
+	if _, err := min.Subtract(one); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MinInt64 - 1 error = %v, want ErrOverflow", err)
+	}
+	if _, err := max.CheckedMultiply(2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MaxInt64 * 2 error = %v, want ErrOverflow", err)
+	}
+	if _, err := min.CheckedMultiply(-1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MinInt64 * -1 error = %v, want ErrOverflow", err)
+	}
+	if _, err := min.CheckedDivide(-1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MinInt64 / -1 error = %v, want ErrOverflow", err)
+	}
+	if _, err := one.CheckedDivide(0); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("1 / 0 error = %v, want ErrDivisionByZero", err)
+	}
+
+	product, err := NewMoneyFromCents(-1250).CheckedMultiply(3)
+	if err != nil || product.Cents() != -3750 {
+		t.Errorf("-12.50 * 3 = %s, %v, want -37.50", product, err)
+	}
+
+	quotient, err := NewMoneyFromCents(-1001).CheckedDivide(2)
+	if err != nil || quotient.Cents() != -501 {
+		t.Errorf("-10.01 / 2 = %s, %v, want -5.01", quotient, err)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		cents    int64
+		ratios   []int
+		expected []int64
+	}{
+		{"even", 10000, []int{1, 1}, []int64{5000, 5000}},
+		{"remainder to first", 10000, []int{1, 1, 1}, []int64{3334, 3333, 3333}},
+		{"fee share", 10005, []int{97, 3}, []int64{9705, 300}},
+		{"zero ratio skipped", 5, []int{0, 1, 1}, []int64{0, 3, 2}},
+		{"negative amount", -10000, []int{1, 1, 1}, []int64{-3334, -3333, -3333}},
+		{"no overflow", math.MaxInt64, []int{3, 1}, []int64{6917529027641081856, 2305843009213693951}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := NewMoneyFromCents(tt.cents).Allocate(tt.ratios...)
+			if err != nil {
+				t.Fatalf("Allocate error: %v", err)
+			}
+			var sum int64
+			for i, part := range parts {
+				if part.Cents() != tt.expected[i] {
+					t.Errorf("part %d = %d, want %d", i, part.Cents(), tt.expected[i])
+				}
+				sum += part.Cents()
+			}
+			if sum != tt.cents {
+				t.Errorf("parts sum to %d, want %d", sum, tt.cents)
+			}
+		})
+	}
+
+	if _, err := NewMoneyFromCents(100).Allocate(); !errors.Is(err, ErrInvalidRatio) {
+		t.Errorf("Allocate() error = %v, want ErrInvalidRatio", err)
+	}
+	if _, err := NewMoneyFromCents(100).Allocate(1, -1); !errors.Is(err, ErrInvalidRatio) {
+		t.Errorf("Allocate(1, -1) error = %v, want ErrInvalidRatio", err)
+	}
+	if _, err := NewMoneyFromCents(100).Allocate(0, 0); !errors.Is(err, ErrInvalidRatio) {
+		t.Errorf("Allocate(0, 0) error = %v, want ErrInvalidRatio", err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	parts, err := NewMoneyFromMinorUnits(1000, JPY).Split(3)
+	if err != nil {
+		t.Fatalf("Split error: %v", err)
+	}
+	expected := []int64{334, 333, 333}
+	for i, part := range parts {
+		if part.Cents() != expected[i] || part.Currency() != JPY {
+			t.Errorf("installment %d = %d %s, want %d JPY", i, part.Cents(), part.Currency(), expected[i])
+		}
+	}
+
+	if _, err := NewMoneyFromCents(100).Split(0); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Split(0) error = %v, want ErrDivisionByZero", err)
+	}
+}
+
+func TestRoundingModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		cents    int64
+		divisor  int64
+		mode     RoundingMode
+		expected int64
+	}{
+		{"half up tie", 25, 10, RoundHalfUp, 3},
+		{"half up negative tie", -25, 10, RoundHalfUp, -3},
+		{"half even tie down", 25, 10, RoundHalfEven, 2},
+		{"half even tie up", 35, 10, RoundHalfEven, 4},
+		{"half even negative tie", -25, 10, RoundHalfEven, -2},
+		{"half down tie", 25, 10, RoundHalfDown, 2},
+		{"half down above tie", 26, 10, RoundHalfDown, 3},
+		{"down", -29, 10, RoundDown, -2},
+		{"up", 21, 10, RoundUp, 3},
+		{"floor positive", 29, 10, RoundFloor, 2},
+		{"floor negative", -21, 10, RoundFloor, -3},
+		{"ceiling positive", 21, 10, RoundCeiling, 3},
+		{"ceiling negative", -29, 10, RoundCeiling, -2},
+		{"exact needs no rounding", 30, 10, RoundUnnecessary, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewMoneyFromCents(tt.cents).DivideRat(big.NewRat(tt.divisor, 1), tt.mode)
+			if err != nil {
+				t.Fatalf("DivideRat error: %v", err)
+			}
+			if result.Cents() != tt.expected {
+				t.Errorf("%d / %d (%s) = %d, want %d", tt.cents, tt.divisor, tt.mode, result.Cents(), tt.expected)
+			}
+		})
+	}
+
+	if _, err := NewMoneyFromCents(25).DivideRat(big.NewRat(10, 1), RoundUnnecessary); !errors.Is(err, ErrRoundingNecessary) {
+		t.Errorf("inexact RoundUnnecessary error = %v, want ErrRoundingNecessary", err)
+	}
+	if _, err := NewMoneyFromCents(25).DivideRat(new(big.Rat), RoundHalfUp); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("DivideRat(0) error = %v, want ErrDivisionByZero", err)
+	}
+}
+
+func TestRateOperations(t *testing.T) {
+	amount := NewMoneyFromCents(123457) // 1234.57
+
+	fee, err := amount.ApplyBasisPoints(275, RoundHalfEven)
+	if err != nil || fee.Cents() != 3395 {
+		t.Errorf("275 bps of 1234.57 = %s, %v, want 33.95", fee, err)
+	}
+
+	fee, err = amount.ApplyBasisPoints(275, RoundCeiling)
+	if err != nil || fee.Cents() != 3396 {
+		t.Errorf("275 bps ceiling of 1234.57 = %s, %v, want 33.96", fee, err)
+	}
+
+	tax, err := amount.ApplyPercent("2.75", RoundHalfEven)
+	if err != nil || tax.Cents() != 3395 {
+		t.Errorf("2.75%% of 1234.57 = %s, %v, want 33.95", tax, err)
+	}
+
+	third, err := NewMoneyFromCents(10000).MultiplyRat(big.NewRat(1, 3), RoundFloor)
+	if err != nil || third.Cents() != 3333 {
+		t.Errorf("100.00 * 1/3 = %s, %v, want 33.33", third, err)
+	}
+
+	if _, err := NewMoneyFromCents(math.MaxInt64).MultiplyRat(big.NewRat(3, 2), RoundDown); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MaxInt64 * 3/2 error = %v, want ErrOverflow", err)
+	}
+
+	if _, err := amount.ApplyPercent("abc", RoundHalfUp); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("ApplyPercent(abc) error = %v, want ErrInvalidFormat", err)
+	}
+}
+
+func TestFormatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter Formatter
+		money     Money
+		expected  string
+	}{
+		{"pt-BR", NewFormatter(LocalePtBR), NewMoneyFromMinorUnits(123456, BRL), "R$ 1.234,56"},
+		{"pt-BR negative", NewFormatter(LocalePtBR), NewMoneyFromMinorUnits(-123456, BRL), "-R$ 1.234,56"},
+		{"en-US", NewFormatter(LocaleEnUS), NewMoneyFromMinorUnits(123456, USD), "$1,234.56"},
+		{"en-US millions", NewFormatter(LocaleEnUS), NewMoneyFromMinorUnits(123456789, USD), "$1,234,567.89"},
+		{"accounting", Formatter{Locale: LocaleEnUS, Display: DisplayNone, Negative: NegativeParentheses}, NewMoneyFromMinorUnits(-123456, USD), "(1,234.56)"},
+		{"accounting with symbol", Formatter{Locale: LocaleEnUS, Negative: NegativeParentheses}, NewMoneyFromMinorUnits(-5, USD), "($0.05)"},
+		{"de-DE symbol after", NewFormatter(LocaleDeDE), NewMoneyFromMinorUnits(123456, EUR), "1.234,56 €"},
+		{"fr-FR", NewFormatter(LocaleFrFR), NewMoneyFromMinorUnits(123456, EUR), "1\u202f234,56 €"},
+		{"ja-JP no decimals", NewFormatter(LocaleJaJP), NewMoneyFromMinorUnits(1234567, JPY), "¥1,234,567"},
+		{"code display", Formatter{Locale: LocaleEnUS, Display: DisplayCode}, NewMoneyFromMinorUnits(1234, KWD), "KWD 1.234"},
+		{"code fallback for symbol", NewFormatter(LocaleEnUS), NewMoneyFromMinorUnits(-1, KWD), "-KWD 0.001"},
+		{"min int64", Formatter{Locale: LocaleEnUS, Display: DisplayNone}, NewMoneyFromCents(math.MinInt64), "-92,233,720,368,547,758.08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted := tt.formatter.Format(tt.money)
+			if formatted != tt.expected {
+				t.Fatalf("Format = %q, want %q", formatted, tt.expected)
+			}
+
+			parsed, err := tt.formatter.Parse(formatted, tt.money.Currency())
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", formatted, err)
+			}
+			if !parsed.Equal(tt.money) {
+				t.Errorf("Parse(%q) = %d, want %d", formatted, parsed.Cents(), tt.money.Cents())
+			}
+		})
+	}
+}
+
+func TestFormatterParse(t *testing.T) {
+	f := NewFormatter(LocalePtBR)
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"R$ 1.234,56", 123456},
+		{"R$1.234,56", 123456},
+		{"1.234,56", 123456},
+		{"BRL 10,00", 1000},
+		{"R$ -1,50", -150},
+		{"(R$ 1,50)", -150},
+		{"1,50 R$", 150},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			m, err := f.Parse(tt.input, BRL)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+			if m.Cents() != tt.expected {
+				t.Errorf("Parse(%q) = %d, want %d", tt.input, m.Cents(), tt.expected)
+			}
+		})
+	}
+
+	for _, input := range []string{"(-R$ 1,50)", "$ 1,50", "R$ 1,505"} {
+		if _, err := f.Parse(input, BRL); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSONForms(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int64
+		currency Currency
+	}{
+		{"string", `"150.75"`, 15075, DefaultCurrency},
+		{"number", `150.75`, 15075, DefaultCurrency},
+		{"integer number", `150`, 15000, DefaultCurrency},
+		{"negative number", `-0.5`, -50, DefaultCurrency},
+		{"exponent", `1.5075e2`, 15075, DefaultCurrency},
+		{"negative exponent", `15075E-2`, 15075, DefaultCurrency},
+		{"beyond float precision", `92233720368547758.07`, math.MaxInt64, DefaultCurrency},
+		{"object cents", `{"amount_cents": 15075}`, 15075, DefaultCurrency},
+		{"object cents with currency", `{"amount_cents": 1500, "currency": "jpy"}`, 1500, JPY},
+		{"object amount", `{"amount": "1.234", "currency": "KWD"}`, 1234, KWD},
+		{"object amount and cents", `{"amount": 150.75, "amount_cents": 15075}`, 15075, DefaultCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Money
+			if err := json.Unmarshal([]byte(tt.input), &m); err != nil {
+				t.Fatalf("Unmarshal(%s) error: %v", tt.input, err)
+			}
+			if m.Cents() != tt.expected || m.Currency() != tt.currency {
+				t.Errorf("Unmarshal(%s) = %d %s, want %d %s", tt.input, m.Cents(), m.Currency(), tt.expected, tt.currency)
+			}
+		})
+	}
+
+	for _, input := range []string{`0.005`, `1e999`, `true`, `{}`, `{"amount_cents": 1.5}`, `{"amount": "1.00", "amount_cents": 101}`, `{"amount_cents": 1, "currency": "XYZ1"}`} {
+		var m Money
+		if err := json.Unmarshal([]byte(input), &m); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want error", input)
+		}
+	}
+}
+
+func TestMoneyMarshalJSONFormats(t *testing.T) {
+	m := NewMoneyFromMinorUnits(-15075, BRL)
+	tests := []struct {
+		format   JSONFormat
+		expected string
+	}{
+		{JSONFormatString, `"-150.75"`},
+		{JSONFormatNumber, `-150.75`},
+		{JSONFormatCents, `{"amount_cents":-15075,"currency":"BRL"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			data, err := json.Marshal(m.WithJSONFormat(tt.format))
+			if err != nil {
+				t.Fatalf("Marshal error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Marshal = %s, want %s", data, tt.expected)
+			}
+
+			var back Money
+			if err := json.Unmarshal(data, &back); err != nil || !back.Equal(m) {
+				t.Errorf("round trip = %s, %v, want %s", back, err, m)
+			}
+		})
+	}
+}
+
+func TestApplyJSONFormat(t *testing.T) {
+	type line struct {
+		Amount Money  `json:"amount"`
+		Note   *Money `json:"note,omitempty"`
+	}
+	type response struct {
+		Total  Money            `json:"total"`
+		Lines  []line           `json:"lines"`
+		ByName map[string]Money `json:"by_name"`
+		Extra  interface{}      `json:"extra"`
+	}
+
+	note := NewMoneyFromCents(5)
+	original := &response{
+		Total:  NewMoneyFromCents(15075),
+		Lines:  []line{{Amount: NewMoneyFromCents(-100), Note: &note}},
+		ByName: map[string]Money{"fee": NewMoneyFromCents(250)},
+		Extra:  NewMoneyFromCents(1),
+	}
+
+	data, err := json.Marshal(ApplyJSONFormat(original, JSONFormatCents))
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	expected := `{"total":{"amount_cents":15075,"currency":"BRL"},` +
+		`"lines":[{"amount":{"amount_cents":-100,"currency":"BRL"},"note":{"amount_cents":5,"currency":"BRL"}}],` +
+		`"by_name":{"fee":{"amount_cents":250,"currency":"BRL"}},` +
+		`"extra":{"amount_cents":1,"currency":"BRL"}}`
+	if string(data) != expected {
+		t.Errorf("Marshal = %s, want %s", data, expected)
+	}
+
+	// The original keeps the default string format
+	data, err = json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	expected = `{"total":"150.75","lines":[{"amount":"-1.00","note":"0.05"}],"by_name":{"fee":"2.50"},"extra":"0.01"}`
+	if string(data) != expected {
+		t.Errorf("Marshal original = %s, want %s", data, expected)
+	}
+}
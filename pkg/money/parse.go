@@ -0,0 +1,155 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidFormat is returned when a string is not a decimal amount
+var ErrInvalidFormat = errors.New("invalid money format")
+
+// ParseOptions controls how Parse reads a decimal amount
+type ParseOptions struct {
+	// Locale selects the decimal and group separators.
+	// The zero value accepts '.' as decimal separator and no grouping.
+	Locale Locale
+	// Rounding is applied to fractional digits beyond the currency's exponent.
+	// The zero value, RoundUnnecessary, rejects them.
+	Rounding RoundingMode
+}
+
+// Parse reads a decimal amount such as "-1,234.56" into minor units of the given currency
+// using integer arithmetic only, so no precision is lost for any int64 amount
+func Parse(s string, currency Currency, opts ParseOptions) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, fmt.Errorf("%w: empty string", ErrInvalidFormat)
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	separator := opts.Locale.decimalSeparator()
+	if i := strings.IndexRune(s, separator); i >= 0 {
+		intPart, fracPart = s[:i], s[i+utf8.RuneLen(separator):]
+	}
+
+	intDigits, err := ungroup(intPart, opts.Locale)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", err, s)
+	}
+	if intDigits == "" && fracPart == "" {
+		return Money{}, fmt.Errorf("%w: no digits in %q", ErrInvalidFormat, s)
+	}
+	if !isDigits(fracPart) {
+		return Money{}, fmt.Errorf("%w: invalid fraction in %q", ErrInvalidFormat, s)
+	}
+
+	exp := currency.Exponent()
+	kept, discarded := fracPart, ""
+	if len(fracPart) > exp {
+		kept, discarded = fracPart[:exp], fracPart[exp:]
+	} else {
+		kept += strings.Repeat("0", exp-len(fracPart))
+	}
+
+	// The magnitude of math.MinInt64 is one more than math.MaxInt64
+	limit := uint64(math.MaxInt64)
+	if negative {
+		limit++
+	}
+
+	var magnitude uint64
+	for _, d := range intDigits + kept {
+		digit := uint64(d - '0')
+		if magnitude > (limit-digit)/10 {
+			return Money{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+		}
+		magnitude = magnitude*10 + digit
+	}
+
+	bump, err := opts.Rounding.increment(negative, magnitude%2 == 1, compareHalf(discarded), strings.Trim(discarded, "0") != "")
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places for %s", err, s, exp, currency.Code())
+	}
+	if bump {
+		if magnitude == limit {
+			return Money{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+		}
+		magnitude++
+	}
+
+	amount := int64(magnitude)
+	if negative {
+		amount = int64(-magnitude)
+	}
+	return Money{amount: amount, currency: currency.Code()}, nil
+}
+
+// ungroup validates digit grouping in the integer part and returns its bare digits
+func ungroup(s string, locale Locale) (string, error) {
+	var groups []string
+	var group strings.Builder
+	for _, r := range s {
+		if locale.isGroupSeparator(r) {
+			groups = append(groups, group.String())
+			group.Reset()
+			continue
+		}
+		group.WriteRune(r)
+	}
+	groups = append(groups, group.String())
+
+	if len(groups) == 1 {
+		if !isDigits(s) {
+			return "", ErrInvalidFormat
+		}
+		return s, nil
+	}
+
+	for i, g := range groups {
+		if g == "" || !isDigits(g) {
+			return "", ErrInvalidFormat
+		}
+		if (i == 0 && len(g) > 3) || (i > 0 && len(g) != 3) {
+			return "", fmt.Errorf("%w: misplaced group separator", ErrInvalidFormat)
+		}
+	}
+	return strings.Join(groups, ""), nil
+}
+
+// compareHalf compares the discarded fractional digits with one half unit
+func compareHalf(discarded string) int {
+	if discarded == "" {
+		return -1
+	}
+	switch {
+	case discarded[0] > '5':
+		return 1
+	case discarded[0] < '5':
+		return -1
+	case strings.Trim(discarded[1:], "0") != "":
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// basisPointsPerUnit is the number of basis points in 100%
+const basisPointsPerUnit = 10000
+
+// MultiplyRat multiplies the value by an exact rational factor, rounding the result with mode
+func (m Money) MultiplyRat(factor *big.Rat, mode RoundingMode) (Money, error) {
+	return m.mulDiv(factor.Num(), factor.Denom(), mode)
+}
+
+// DivideRat divides the value by an exact rational divisor, rounding the result with mode
+func (m Money) DivideRat(divisor *big.Rat, mode RoundingMode) (Money, error) {
+	if divisor.Sign() == 0 {
+		return Money{}, ErrDivisionByZero
+	}
+	return m.mulDiv(divisor.Denom(), divisor.Num(), mode)
+}
+
+// ApplyBasisPoints returns bps/10000 of the value, e.g. 275 bps of 100.00 is 2.75
+func (m Money) ApplyBasisPoints(bps int64, mode RoundingMode) (Money, error) {
+	return m.mulDiv(big.NewInt(bps), big.NewInt(basisPointsPerUnit), mode)
+}
+
+// ApplyPercent returns the given percentage of the value.
+// percent is an exact decimal or fraction string such as "2.75" or "1/3".
+func (m Money) ApplyPercent(percent string, mode RoundingMode) (Money, error) {
+	rate, ok := new(big.Rat).SetString(percent)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: invalid percentage %q", ErrInvalidFormat, percent)
+	}
+	denominator := new(big.Int).Mul(rate.Denom(), big.NewInt(100))
+	return m.mulDiv(rate.Num(), denominator, mode)
+}
+
+// mulDiv computes amount * numerator / denominator exactly and rounds the quotient with mode
+func (m Money) mulDiv(numerator, denominator *big.Int, mode RoundingMode) (Money, error) {
+	if denominator.Sign() == 0 {
+		return Money{}, ErrDivisionByZero
+	}
+
+	n := new(big.Int).Mul(big.NewInt(m.amount), numerator)
+	d := new(big.Int).Set(denominator)
+	if d.Sign() < 0 {
+		n.Neg(n)
+		d.Neg(d)
+	}
+
+	quotient, remainder := new(big.Int).QuoRem(n, d, new(big.Int))
+	if remainder.Sign() != 0 {
+		twice := new(big.Int).Abs(remainder)
+		twice.Lsh(twice, 1)
+
+		negative := n.Sign() < 0
+		bump, err := mode.increment(negative, quotient.Bit(0) == 1, twice.Cmp(d), true)
+		if err != nil {
+			return Money{}, fmt.Errorf("%w: %s * %s / %s", err, m, numerator, denominator)
+		}
+		if bump && negative {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else if bump {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	if !quotient.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s * %s / %s", ErrOverflow, m, numerator, denominator)
+	}
+	return Money{amount: quotient.Int64(), currency: m.Currency()}, nil
+}
@@ -0,0 +1,92 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRoundingNecessary is returned when a value cannot be represented exactly
+// and RoundUnnecessary was requested
+var ErrRoundingNecessary = errors.New("rounding necessary")
+
+// RoundingMode selects how a value that falls between two minor units is rounded
+type RoundingMode int
+
+const (
+	// RoundUnnecessary rejects any value that would need rounding
+	RoundUnnecessary RoundingMode = iota
+	// RoundHalfUp rounds to the nearest unit, ties away from zero
+	RoundHalfUp
+	// RoundHalfEven rounds to the nearest unit, ties to the even neighbour (banker's rounding)
+	RoundHalfEven
+	// RoundDown truncates towards zero
+	RoundDown
+	// RoundFloor rounds towards negative infinity
+	RoundFloor
+	// RoundCeiling rounds towards positive infinity
+	RoundCeiling
+	// RoundHalfDown rounds to the nearest unit, ties towards zero
+	RoundHalfDown
+	// RoundUp rounds away from zero
+	RoundUp
+)
+
+var roundingModeNames = map[RoundingMode]string{
+	RoundUnnecessary: "unnecessary",
+	RoundHalfUp:      "half_up",
+	RoundHalfEven:    "half_even",
+	RoundDown:        "down",
+	RoundFloor:       "floor",
+	RoundCeiling:     "ceiling",
+	RoundHalfDown:    "half_down",
+	RoundUp:          "up",
+}
+
+// String returns the name of the rounding mode
+func (mode RoundingMode) String() string {
+	if name, ok := roundingModeNames[mode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(mode))
+}
+
+// ParseRoundingMode returns the rounding mode with the given name, e.g. "half_even"
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	for mode, n := range roundingModeNames {
+		if n == name {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rounding mode %q", name)
+}
+
+// increment decides whether a truncated magnitude must be bumped by one unit.
+// negative is the sign of the exact value, odd whether the truncated magnitude is odd,
+// half compares the discarded remainder with one half unit (-1, 0, 1) and inexact
+// reports whether anything was discarded at all.
+func (mode RoundingMode) increment(negative, odd bool, half int, inexact bool) (bool, error) {
+	if !inexact {
+		return false, nil
+	}
+
+	switch mode {
+	case RoundUnnecessary:
+		return false, ErrRoundingNecessary
+	case RoundHalfUp:
+		return half >= 0, nil
+	case RoundHalfEven:
+		return half > 0 || (half == 0 && odd), nil
+	case RoundHalfDown:
+		return half > 0, nil
+	case RoundDown:
+		return false, nil
+	case RoundFloor:
+		return negative, nil
+	case RoundCeiling:
+		return !negative, nil
+	case RoundUp:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported rounding mode %s", mode)
+	}
+}
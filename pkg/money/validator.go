@@ -1,20 +1,94 @@
 package money
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
 
-// RegisterValidators registers custom validators for Money type
+// RegisterValidators registers the Money type and its validation tags:
+//
+//	money_positive        value > 0
+//	money_min=10.00       value >= 10.00 in the value's currency
+//	money_max=5000.00     value <= 5000.00 in the value's currency
+//	money_currency=BRL USD currency is one of the space separated codes
 func RegisterValidators(v *validator.Validate) {
 	v.RegisterCustomTypeFunc(ValidateMoney, Money{})
+
+	// Registration only fails for empty tags or nil functions
+	_ = v.RegisterValidation("money_positive", validatePositive)
+	_ = v.RegisterValidation("money_min", validateMin)
+	_ = v.RegisterValidation("money_max", validateMax)
+	_ = v.RegisterValidation("money_currency", validateCurrency)
 }
 
-// ValidateMoney validates Money values for the validator package
+// ValidateMoney validates Money values for the validator package.
+// The validator skips tags on struct fields, so Money is handed to it as a
+// "<currency> <amount>" string such as "BRL 150.75" that the money_* tags decode.
 func ValidateMoney(field reflect.Value) interface{} {
 	if money, ok := field.Interface().(Money); ok {
-		return money.String()
+		return money.Currency().String() + " " + money.String()
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// fieldMoney decodes the value produced by ValidateMoney
+func fieldMoney(fl validator.FieldLevel) (Money, bool) {
+	code, amount, ok := strings.Cut(fl.Field().String(), " ")
+	if !ok {
+		return Money{}, false
+	}
+	currency, err := ParseCurrency(code)
+	if err != nil {
+		return Money{}, false
+	}
+	money, err := NewMoneyFromStringInCurrency(amount, currency)
+	return money, err == nil
+}
+
+// boundParam parses a money_min/money_max parameter in the value's currency.
+// Bounds finer than the currency's minor unit are rounded inwards, so money_min=0.5
+// on a JPY value requires at least 1 yen.
+func boundParam(fl validator.FieldLevel, currency Currency, rounding RoundingMode) Money {
+	bound, err := Parse(fl.Param(), currency, ParseOptions{Rounding: rounding})
+	if err != nil {
+		panic(fmt.Sprintf("money: invalid %s parameter %q: %v", fl.GetTag(), fl.Param(), err))
+	}
+	return bound
+}
+
+func validatePositive(fl validator.FieldLevel) bool {
+	money, ok := fieldMoney(fl)
+	return ok && money.IsPositive()
+}
+
+func validateMin(fl validator.FieldLevel) bool {
+	money, ok := fieldMoney(fl)
+	if !ok {
+		return false
+	}
+	return !money.LessThan(boundParam(fl, money.Currency(), RoundCeiling))
+}
+
+func validateMax(fl validator.FieldLevel) bool {
+	money, ok := fieldMoney(fl)
+	if !ok {
+		return false
+	}
+	return !money.GreaterThan(boundParam(fl, money.Currency(), RoundFloor))
+}
+
+func validateCurrency(fl validator.FieldLevel) bool {
+	money, ok := fieldMoney(fl)
+	if !ok {
+		return false
+	}
+	for _, code := range strings.Fields(fl.Param()) {
+		if strings.EqualFold(code, money.Currency().String()) {
+			return true
+		}
+	}
+	return false
+}
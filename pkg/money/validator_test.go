@@ -0,0 +1,54 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestValidators(t *testing.T) {
+	v := validator.New()
+	RegisterValidators(v)
+
+	type payment struct {
+		Value Money `validate:"required,money_positive,money_min=0.50,money_max=1000.00,money_currency=BRL JPY"`
+	}
+
+	tests := []struct {
+		name  string
+		value Money
+		valid bool
+	}{
+		{"within bounds", NewMoneyFromMinorUnits(15075, BRL), true},
+		{"at minimum", NewMoneyFromMinorUnits(50, BRL), true},
+		{"at maximum", NewMoneyFromMinorUnits(100000, BRL), true},
+		{"zero", NewMoneyFromMinorUnits(0, BRL), false},
+		{"negative", NewMoneyFromMinorUnits(-100, BRL), false},
+		{"below minimum", NewMoneyFromMinorUnits(49, BRL), false},
+		{"above maximum", NewMoneyFromMinorUnits(100001, BRL), false},
+		{"currency not allowed", NewMoneyFromMinorUnits(100, USD), false},
+		{"JPY minimum rounds up to 1 yen", NewMoneyFromMinorUnits(1, JPY), true},
+		{"JPY maximum", NewMoneyFromMinorUnits(1001, JPY), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Struct(payment{Value: tt.value})
+			if (err == nil) != tt.valid {
+				t.Errorf("Struct(%s %s) error = %v, want valid=%v", tt.value.Currency(), tt.value, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidatorInvalidParameterPanics(t *testing.T) {
+	v := validator.New()
+	RegisterValidators(v)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for invalid money_min parameter")
+		}
+	}()
+	_ = v.Var(NewMoneyFromCents(100), "money_min=abc")
+}
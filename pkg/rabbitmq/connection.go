@@ -1,23 +1,73 @@
 package rabbitmq
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
+	"sync"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// ErrConnectionClosed is returned when waiting on a connection that was closed with Close
+var ErrConnectionClosed = errors.New("connection closed")
+
 type Config struct {
-	URL          string
-	MaxRetries   int
-	RetryDelay   time.Duration
+	URL        string
+	MaxRetries int
+	RetryDelay time.Duration
+	// ReconnectDelay is the first wait after losing the connection, doubled after each
+	// failed attempt up to MaxReconnectDelay
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+}
+
+// State of a Connection, for health checks. The zero value is StateConnecting, so a
+// Connection that was never set up does not report itself as connected.
+type State int32
+
+const (
+	StateConnecting State = iota
+	StateConnected
+	StateReconnecting
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("State(%d)", int32(s))
+	}
 }
 
+// Connection is a supervised connection with one channel. When the broker closes either,
+// it reconnects in the background, declares the recorded topology again and swaps the
+// channel; consumers resubscribe and publishers move to the new channel on their own.
 type Connection struct {
-	conn *amqp.Connection
-	ch   *amqp.Channel
-	cfg  Config
+	cfg Config
+
+	mu    sync.RWMutex
+	conn  *amqp.Connection
+	ch    *amqp.Channel
+	state State
+	// changed is closed and replaced whenever the channel is swapped or the connection closed
+	changed chan struct{}
+	// topology holds the declarations made so far, replayed on every new channel
+	topology []func(*amqp.Channel) error
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 func NewConnection(cfg Config) (*Connection, error) {
@@ -27,6 +77,12 @@ func NewConnection(cfg Config) (*Connection, error) {
 	if cfg.RetryDelay == 0 {
 		cfg.RetryDelay = 5 * time.Second
 	}
+	if cfg.ReconnectDelay == 0 {
+		cfg.ReconnectDelay = time.Second
+	}
+	if cfg.MaxReconnectDelay == 0 {
+		cfg.MaxReconnectDelay = 30 * time.Second
+	}
 
 	var conn *amqp.Connection
 	var err error
@@ -52,39 +108,233 @@ func NewConnection(cfg Config) (*Connection, error) {
 
 	log.Println("Successfully connected to RabbitMQ")
 
-	return &Connection{
-		conn: conn,
-		ch:   ch,
-		cfg:  cfg,
-	}, nil
+	c := &Connection{
+		cfg:     cfg,
+		conn:    conn,
+		ch:      ch,
+		state:   StateConnected,
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go c.supervise(conn, ch)
+
+	return c, nil
 }
 
 func (c *Connection) Close() error {
-	if c.ch != nil {
-		c.ch.Close()
-	}
-	if c.conn != nil {
-		return c.conn.Close()
-	}
-	return nil
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.state = StateClosed
+		close(c.changed)
+		if c.ch != nil {
+			c.ch.Close()
+		}
+		if c.conn != nil {
+			err = c.conn.Close()
+		}
+	})
+	return err
 }
 
+// Channel returns the current channel, which is replaced after a reconnection
 func (c *Connection) Channel() *amqp.Channel {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.ch
 }
 
 func (c *Connection) IsClosed() bool {
-	return c.conn.IsClosed()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state != StateConnected || c.conn.IsClosed()
+}
+
+// State reports whether the connection is being set up, up, being recovered or closed
+func (c *Connection) State() State {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
 }
 
 func (c *Connection) DeclareQueue(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
-	return c.ch.QueueDeclare(name, durable, autoDelete, exclusive, noWait, args)
+	var queue amqp.Queue
+	err := c.declare(func(ch *amqp.Channel) error {
+		var err error
+		queue, err = ch.QueueDeclare(name, durable, autoDelete, exclusive, noWait, args)
+		return err
+	})
+	return queue, err
 }
 
 func (c *Connection) DeclareExchange(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
-	return c.ch.ExchangeDeclare(name, kind, durable, autoDelete, internal, noWait, args)
+	return c.declare(func(ch *amqp.Channel) error {
+		return ch.ExchangeDeclare(name, kind, durable, autoDelete, internal, noWait, args)
+	})
 }
 
 func (c *Connection) BindQueue(queueName, key, exchange string, noWait bool, args amqp.Table) error {
-	return c.ch.QueueBind(queueName, key, exchange, noWait, args)
-}
\ No newline at end of file
+	return c.declare(func(ch *amqp.Channel) error {
+		return ch.QueueBind(queueName, key, exchange, noWait, args)
+	})
+}
+
+// declare applies a declaration to the current channel and records it for reconnections
+func (c *Connection) declare(fn func(*amqp.Channel) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := fn(c.ch); err != nil {
+		return err
+	}
+	c.topology = append(c.topology, fn)
+	return nil
+}
+
+// channelAfter waits until the channel is no longer old, returning the new one
+func (c *Connection) channelAfter(ctx context.Context, old *amqp.Channel) (*amqp.Channel, error) {
+	for {
+		c.mu.RLock()
+		ch, state, changed := c.ch, c.state, c.changed
+		c.mu.RUnlock()
+
+		if state == StateClosed {
+			return nil, ErrConnectionClosed
+		}
+		if ch != old && state == StateConnected {
+			return ch, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// supervise watches the connection and its channel, recovering them until Close
+func (c *Connection) supervise(conn *amqp.Connection, ch *amqp.Channel) {
+	for {
+		connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		chClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+
+		select {
+		case <-c.done:
+			return
+		case err := <-connClosed:
+			log.Printf("RabbitMQ connection closed: %v", err)
+		case err := <-chClosed:
+			log.Printf("RabbitMQ channel closed: %v", err)
+		}
+
+		var ok bool
+		if conn, ch, ok = c.reconnect(conn); !ok {
+			return
+		}
+	}
+}
+
+// reconnect retries with exponential backoff and jitter until it installs a new channel,
+// reusing the connection if only the channel was closed. It returns false after Close.
+func (c *Connection) reconnect(conn *amqp.Connection) (*amqp.Connection, *amqp.Channel, bool) {
+	c.mu.Lock()
+	if c.state == StateClosed {
+		c.mu.Unlock()
+		return nil, nil, false
+	}
+	c.state = StateReconnecting
+	c.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-c.done:
+			return nil, nil, false
+		case <-time.After(backoff(c.cfg.ReconnectDelay, c.cfg.MaxReconnectDelay, attempt)):
+		}
+
+		newConn, ch, err := c.open(conn)
+		if err != nil {
+			log.Printf("Failed to reconnect to RabbitMQ (attempt %d): %v", attempt, err)
+			if newConn != nil {
+				conn = newConn
+			}
+			continue
+		}
+
+		if !c.install(newConn, ch) {
+			ch.Close()
+			newConn.Close()
+			return nil, nil, false
+		}
+		log.Printf("Reconnected to RabbitMQ after %d attempts", attempt)
+		return newConn, ch, true
+	}
+}
+
+// open returns a channel with the topology declared, dialing again if conn is closed
+func (c *Connection) open(conn *amqp.Connection) (*amqp.Connection, *amqp.Channel, error) {
+	if conn.IsClosed() {
+		var err error
+		if conn, err = amqp.Dial(c.cfg.URL); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return conn, nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+
+	c.mu.RLock()
+	topology := c.topology
+	c.mu.RUnlock()
+
+	for _, declare := range topology {
+		if err := declare(ch); err != nil {
+			ch.Close()
+			return conn, nil, fmt.Errorf("failed to declare topology: %w", err)
+		}
+	}
+	return conn, ch, nil
+}
+
+// install swaps in the new connection and channel unless Close was called meanwhile
+func (c *Connection) install(conn *amqp.Connection, ch *amqp.Channel) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == StateClosed {
+		return false
+	}
+	if c.conn != conn {
+		c.conn.Close()
+	}
+	c.conn, c.ch, c.state = conn, ch, StateConnected
+	close(c.changed)
+	c.changed = make(chan struct{})
+	return true
+}
+
+// backoff is the wait before a reconnection attempt, with jitter so clients restarted
+// together do not reconnect in lockstep
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	delay := exponential(base, max, attempt)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// exponential is base doubled for each attempt after the first, capped at max
+func exponential(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
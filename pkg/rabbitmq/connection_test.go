@@ -0,0 +1,74 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestBackoff(t *testing.T) {
+	base, max := time.Second, 30*time.Second
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{6, 30 * time.Second},
+		{100, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if got := backoff(base, max, tt.attempt); got < tt.want/2 || got > tt.want {
+				t.Fatalf("backoff(attempt %d) = %v, want between %v and %v", tt.attempt, got, tt.want/2, tt.want)
+			}
+		}
+	}
+}
+
+func TestChannelAfter(t *testing.T) {
+	old, next := &amqp.Channel{}, &amqp.Channel{}
+	c := &Connection{ch: old, state: StateReconnecting, changed: make(chan struct{}), done: make(chan struct{})}
+
+	result := make(chan *amqp.Channel)
+	go func() {
+		ch, _ := c.channelAfter(context.Background(), old)
+		result <- ch
+	}()
+
+	c.mu.Lock()
+	c.ch, c.state = next, StateConnected
+	close(c.changed)
+	c.changed = make(chan struct{})
+	c.mu.Unlock()
+
+	select {
+	case ch := <-result:
+		if ch != next {
+			t.Error("channelAfter returned the old channel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channelAfter did not return after the channel was swapped")
+	}
+
+	c.mu.Lock()
+	c.state = StateClosed
+	close(c.changed)
+	c.mu.Unlock()
+	if _, err := c.channelAfter(context.Background(), next); !errors.Is(err, ErrConnectionClosed) {
+		t.Errorf("channelAfter on a closed connection error = %v, want ErrConnectionClosed", err)
+	}
+}
+
+func TestZeroConnectionIsNotConnected(t *testing.T) {
+	c := &Connection{}
+	if state := c.State(); state != StateConnecting {
+		t.Errorf("State() = %v, want connecting", state)
+	}
+}
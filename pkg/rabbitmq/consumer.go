@@ -3,29 +3,158 @@ package rabbitmq
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
 	"log"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// Handler outcomes, matched by errors.Is on the error a MessageHandler returns
+var (
+	// ErrRetry retries the message after a backoff, up to the retry limit
+	ErrRetry = errors.New("message retried")
+	// ErrReject moves the message to the dead letter queue without retrying
+	ErrReject = errors.New("message rejected")
+	// ErrDrop acknowledges and discards the message
+	ErrDrop = errors.New("message dropped")
+)
+
+// MessageHandler processes a message body. Returning nil acks the message. An error
+// wrapping ErrReject dead-letters it and one wrapping ErrDrop discards it; any other
+// error, including one wrapping ErrRetry, retries it.
 type MessageHandler func(ctx context.Context, body []byte) error
 
+// Retry marks err as temporary so the message is tried again later
+func Retry(err error) error {
+	return fmt.Errorf("%w: %w", ErrRetry, err)
+}
+
+// Reject marks err as permanent so the message goes to the dead letter queue instead of being retried
+func Reject(err error) error {
+	return fmt.Errorf("%w: %w", ErrReject, err)
+}
+
+// Drop marks a message as not worth keeping, so it is acknowledged without being processed
+func Drop(err error) error {
+	return fmt.Errorf("%w: %w", ErrDrop, err)
+}
+
+// ConsumerOptions configure how messages are processed and how failed ones are handled
+type ConsumerOptions struct {
+	Retry RetryPolicy
+	// OnRetriesExhausted is called with the last error when a message is dead-lettered
+	// because it failed all its retries
+	OnRetriesExhausted func(ctx context.Context, body []byte, err error)
+
+	// Workers is how many messages are handled at once, 1 if zero
+	Workers int
+	// Prefetch is how many unacknowledged deliveries the broker sends ahead of the workers,
+	// Workers if zero. It bounds the messages the consumer holds in memory.
+	Prefetch int
+	// OrderingKey, if set, sends messages with the same key to the same worker, which
+	// handles them one at a time in delivery order. Messages with an empty key go to any
+	// worker. Order is kept among deliveries only: a retried message comes back later.
+	OrderingKey func(body []byte) string
+}
+
+// ConsumerStats are counters of the work of a consumer
+type ConsumerStats struct {
+	Workers  int `json:"workers"`
+	Prefetch int `json:"prefetch"`
+	// InFlight deliveries were received and not yet settled, Active ones are being handled
+	InFlight int64 `json:"in_flight"`
+	Active   int64 `json:"active"`
+	Handled  int64 `json:"handled"`
+}
+
+// Consumer processes the deliveries of a queue with a pool of workers. Failed messages are
+// moved to retry queues that send them back after a backoff, and finally to the dead letter
+// queue; see RetryPolicy.
 type Consumer struct {
-	conn    *Connection
-	queue   string
-	handler MessageHandler
+	conn      *Connection
+	queue     string
+	handler   MessageHandler
+	opts      ConsumerOptions
+	publisher *Publisher
+
+	inFlight atomic.Int64
+	active   atomic.Int64
+	handled  atomic.Int64
+
+	// stopped is closed once the consumer stopped and its workers returned
+	stopped chan struct{}
 }
 
-func NewConsumer(conn *Connection, queue string, handler MessageHandler) *Consumer {
+func NewConsumer(conn *Connection, queue string, handler MessageHandler, opts ConsumerOptions) *Consumer {
+	opts.Retry = opts.Retry.withDefaults()
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.Prefetch <= 0 {
+		opts.Prefetch = opts.Workers
+	}
 	return &Consumer{
-		conn:    conn,
-		queue:   queue,
-		handler: handler,
+		conn:      conn,
+		queue:     queue,
+		handler:   handler,
+		opts:      opts,
+		publisher: NewPublisher(conn, ""),
+		stopped:   make(chan struct{}),
 	}
 }
 
+// Start subscribes to the queue and processes deliveries in the background until ctx is
+// done. After the connection recovers from a broker restart it subscribes again.
+//
+// Messages being handled when ctx is done are finished; those received but not started are
+// requeued. Wait returns once that is over.
 func (c *Consumer) Start(ctx context.Context) error {
-	msgs, err := c.conn.ch.Consume(
+	if err := declareRetryTopology(c.conn, c.queue, c.opts.Retry); err != nil {
+		return fmt.Errorf("failed to declare retry topology: %w", err)
+	}
+
+	ch := c.conn.Channel()
+	msgs, err := c.consume(ch)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Consumer started for queue: %s", c.queue)
+
+	go c.run(ctx, ch, msgs)
+
+	return nil
+}
+
+// Wait blocks until a started consumer has stopped and no message is being handled
+func (c *Consumer) Wait() {
+	<-c.stopped
+}
+
+// Stats returns the current counters of the consumer
+func (c *Consumer) Stats() ConsumerStats {
+	return ConsumerStats{
+		Workers:  c.opts.Workers,
+		Prefetch: c.opts.Prefetch,
+		InFlight: c.inFlight.Load(),
+		Active:   c.active.Load(),
+		Handled:  c.handled.Load(),
+	}
+}
+
+// consume sets the prefetch of the channel and subscribes to the queue. It runs again on
+// each new channel after a reconnection, as QoS does not outlive its channel.
+func (c *Consumer) consume(ch *amqp.Channel) (<-chan amqp.Delivery, error) {
+	if err := ch.Qos(c.opts.Prefetch, 0, false); err != nil {
+		return nil, fmt.Errorf("failed to set prefetch: %w", err)
+	}
+	return ch.Consume(
 		c.queue,
 		"",    // consumer tag
 		false, // auto-ack (we'll manually ack after processing)
@@ -34,50 +163,223 @@ func (c *Consumer) Start(ctx context.Context) error {
 		false, // no-wait
 		nil,   // args
 	)
-	if err != nil {
-		return err
-	}
+}
 
-	log.Printf("Consumer started for queue: %s", c.queue)
+func (c *Consumer) run(ctx context.Context, ch *amqp.Channel, msgs <-chan amqp.Delivery) {
+	defer close(c.stopped)
 
-	go func() {
+	for {
+		c.process(ctx, msgs)
+		if ctx.Err() != nil {
+			log.Printf("Consumer stopping for queue: %s", c.queue)
+			return
+		}
+
+		log.Printf("Consumer channel closed for queue: %s, waiting for reconnection", c.queue)
 		for {
-			select {
-			case <-ctx.Done():
-				log.Printf("Consumer stopping for queue: %s", c.queue)
+			next, err := c.conn.channelAfter(ctx, ch)
+			if err != nil {
+				log.Printf("Consumer stopping for queue: %s: %v", c.queue, err)
 				return
-			case delivery, ok := <-msgs:
-				_ = amqp.Delivery{} // Force usage of amqp import
-				if !ok {
-					log.Printf("Consumer channel closed for queue: %s", c.queue)
-					return
-				}
-
-				if err := c.handler(ctx, delivery.Body); err != nil {
-					log.Printf("Error processing message: %v", err)
-					if nackErr := delivery.Nack(false, true); nackErr != nil {
-						log.Printf("Failed to nack message: %v", nackErr)
-					}
-				} else {
-					if ackErr := delivery.Ack(false); ackErr != nil {
-						log.Printf("Failed to ack message: %v", ackErr)
-					}
-				}
 			}
+			ch = next
+
+			// A failed subscription closes the channel, so the next one is awaited
+			if msgs, err = c.consume(ch); err == nil {
+				break
+			}
+			log.Printf("Failed to resubscribe to queue %s: %v", c.queue, err)
+		}
+		log.Printf("Consumer resubscribed to queue: %s", c.queue)
+	}
+}
+
+// process hands deliveries to the workers until ctx is done or the channel closes, then
+// waits for the workers to settle the deliveries they were given
+func (c *Consumer) process(ctx context.Context, msgs <-chan amqp.Delivery) {
+	// Unkeyed deliveries go to whichever worker is free. Each worker also has its own queue
+	// for keyed deliveries, sized by the prefetch so handing them over never blocks.
+	shared := make(chan amqp.Delivery)
+	own := make([]chan amqp.Delivery, c.opts.Workers)
+
+	var wg sync.WaitGroup
+	for i := range own {
+		own[i] = make(chan amqp.Delivery, c.opts.Prefetch)
+		wg.Add(1)
+		go func(own <-chan amqp.Delivery) {
+			defer wg.Done()
+			c.work(ctx, own, shared)
+		}(own[i])
+	}
+	defer func() {
+		close(shared)
+		for _, ch := range own {
+			close(ch)
 		}
+		wg.Wait()
 	}()
 
-	return nil
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case delivery, ok := <-msgs:
+			if !ok {
+				return
+			}
+
+			c.inFlight.Add(1)
+			if key := c.orderingKey(delivery.Body); key != "" {
+				own[workerFor(key, len(own))] <- delivery
+			} else {
+				shared <- delivery
+			}
+		}
+	}
+}
+
+// work handles the deliveries of both queues until they are closed. Once ctx is done the
+// deliveries still queued are requeued instead of handled. Handlers run without ctx's
+// cancellation, so a message being handled when the consumer stops is finished rather than
+// failed and retried.
+func (c *Consumer) work(ctx context.Context, own, shared <-chan amqp.Delivery) {
+	handleCtx := context.WithoutCancel(ctx)
+	for own != nil || shared != nil {
+		var delivery amqp.Delivery
+		var ok bool
+		select {
+		case delivery, ok = <-own:
+			if !ok {
+				own = nil
+				continue
+			}
+		case delivery, ok = <-shared:
+			if !ok {
+				shared = nil
+				continue
+			}
+		}
+
+		if ctx.Err() != nil {
+			if err := delivery.Nack(false, true); err != nil {
+				log.Printf("Failed to requeue message: %v", err)
+			}
+			c.inFlight.Add(-1)
+			continue
+		}
+
+		c.active.Add(1)
+		c.handle(handleCtx, delivery)
+		c.active.Add(-1)
+		c.inFlight.Add(-1)
+		c.handled.Add(1)
+	}
+}
+
+func (c *Consumer) orderingKey(body []byte) string {
+	if c.opts.OrderingKey == nil {
+		return ""
+	}
+	return c.opts.OrderingKey(body)
+}
+
+// workerFor picks the worker of an ordering key
+func workerFor(key string, workers int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(workers))
+}
+
+// handle runs the handler and settles the delivery according to its outcome
+func (c *Consumer) handle(ctx context.Context, delivery amqp.Delivery) {
+	err := c.handler(ctx, delivery.Body)
+	switch {
+	case err == nil:
+		c.ack(delivery)
+	case errors.Is(err, ErrDrop):
+		log.Printf("Dropping message: %v", err)
+		c.ack(delivery)
+	case errors.Is(err, ErrReject):
+		log.Printf("Rejecting message to the dead letter queue: %v", err)
+		c.settle(delivery, c.deadLetter(ctx, delivery, err))
+	default:
+		retries := retryCount(delivery.Headers, c.queue)
+		if retries >= c.opts.Retry.MaxRetries {
+			log.Printf("Giving up on message after %d retries: %v", retries, err)
+			if c.opts.OnRetriesExhausted != nil {
+				c.opts.OnRetriesExhausted(ctx, delivery.Body, err)
+			}
+			c.settle(delivery, c.deadLetter(ctx, delivery, fmt.Errorf("failed after %d retries: %w", retries, err)))
+			return
+		}
+
+		delay := c.opts.Retry.delay(retries + 1)
+		log.Printf("Error processing message, retry %d/%d in %v: %v", retries+1, c.opts.Retry.MaxRetries, delay, err)
+		c.settle(delivery, c.retryLater(ctx, delivery, retries+1, delay))
+	}
+}
+
+// retryLater parks a copy of the message in the retry queue of its retry, which sends it
+// back to the consumed queue once the message TTL expires
+func (c *Consumer) retryLater(ctx context.Context, delivery amqp.Delivery, retry int, delay time.Duration) error {
+	headers := failureHeaders(delivery)
+	headers[HeaderRetryCount] = int32(retry)
+
+	return c.publisher.PublishMessage(ctx, "", RetryQueue(c.queue, retry), amqp.Publishing{
+		Headers:     headers,
+		MessageId:   delivery.MessageId,
+		ContentType: delivery.ContentType,
+		Body:        delivery.Body,
+		Timestamp:   delivery.Timestamp,
+		Expiration:  fmt.Sprint(delay.Milliseconds()),
+	})
+}
+
+// deadLetter publishes a copy of the message to the dead letter exchange with the reason
+func (c *Consumer) deadLetter(ctx context.Context, delivery amqp.Delivery, reason error) error {
+	headers := failureHeaders(delivery)
+	headers[HeaderFailureReason] = reason.Error()
+	headers[HeaderFailedAt] = time.Now().UTC().Format(time.RFC3339)
+
+	return c.publisher.PublishMessage(ctx, DeadLetterExchange(c.queue), c.queue, amqp.Publishing{
+		Headers:     headers,
+		MessageId:   delivery.MessageId,
+		ContentType: delivery.ContentType,
+		Body:        delivery.Body,
+		Timestamp:   delivery.Timestamp,
+	})
+}
+
+// settle acks a message once its copy was moved to another queue. If moving it failed,
+// the message is requeued so it is not lost.
+func (c *Consumer) settle(delivery amqp.Delivery, moveErr error) {
+	if moveErr != nil {
+		log.Printf("Failed to move message, requeueing it: %v", moveErr)
+		if nackErr := delivery.Nack(false, true); nackErr != nil {
+			log.Printf("Failed to nack message: %v", nackErr)
+		}
+		return
+	}
+	c.ack(delivery)
+}
+
+func (c *Consumer) ack(delivery amqp.Delivery) {
+	if ackErr := delivery.Ack(false); ackErr != nil {
+		log.Printf("Failed to ack message: %v", ackErr)
+	}
 }
 
 func (c *Consumer) StartJSONConsumer(ctx context.Context, handler func(ctx context.Context, message interface{}) error, messageType interface{}) error {
+	// Workers decode concurrently, so each message gets its own value of the pointed-to type
+	elem := reflect.TypeOf(messageType).Elem()
 	jsonHandler := func(ctx context.Context, body []byte) error {
-		if err := json.Unmarshal(body, messageType); err != nil {
-			return err
+		message := reflect.New(elem).Interface()
+		if err := json.Unmarshal(body, message); err != nil {
+			return Reject(err)
 		}
-		return handler(ctx, messageType)
+		return handler(ctx, message)
 	}
 
 	c.handler = jsonHandler
 	return c.Start(ctx)
-}
\ No newline at end of file
+}
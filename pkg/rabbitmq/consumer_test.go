@@ -0,0 +1,187 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// fakeAcknowledger records the delivery tags a consumer acked and requeued
+type fakeAcknowledger struct {
+	mu       sync.Mutex
+	acked    []uint64
+	requeued []uint64
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acked = append(a.acked, tag)
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if requeue {
+		a.requeued = append(a.requeued, tag)
+	}
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error { return nil }
+
+func newTestConsumer(handler MessageHandler, opts ConsumerOptions) *Consumer {
+	return NewConsumer(&Connection{}, "transaction.register", handler, opts)
+}
+
+// deliveries returns a closed channel holding a delivery per body
+func deliveries(ack *fakeAcknowledger, bodies ...string) <-chan amqp.Delivery {
+	msgs := make(chan amqp.Delivery, len(bodies))
+	for i, body := range bodies {
+		msgs <- amqp.Delivery{Acknowledger: ack, DeliveryTag: uint64(i + 1), Body: []byte(body)}
+	}
+	close(msgs)
+	return msgs
+}
+
+func TestConsumerHandlesMessagesConcurrently(t *testing.T) {
+	const workers = 4
+	started := make(chan struct{}, workers)
+	release := make(chan struct{})
+	consumer := newTestConsumer(func(ctx context.Context, body []byte) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}, ConsumerOptions{Workers: workers})
+
+	ack := &fakeAcknowledger{}
+	done := make(chan struct{})
+	go func() {
+		consumer.process(context.Background(), deliveries(ack, "1", "2", "3", "4"))
+		close(done)
+	}()
+
+	for i := 0; i < workers; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatalf("%d messages handled at once, want %d", i, workers)
+		}
+	}
+	if stats := consumer.Stats(); stats.Active != workers || stats.InFlight != workers {
+		t.Errorf("stats = %+v, want %d active and in flight", stats, workers)
+	}
+
+	close(release)
+	<-done
+	if stats := consumer.Stats(); stats.Active != 0 || stats.InFlight != 0 || stats.Handled != workers {
+		t.Errorf("stats = %+v, want %d handled and nothing in flight", stats, workers)
+	}
+	if len(ack.acked) != workers {
+		t.Errorf("acked %v, want all %d messages", ack.acked, workers)
+	}
+}
+
+func TestConsumerKeepsOrderPerKey(t *testing.T) {
+	var mu sync.Mutex
+	handled := map[string][]string{}
+	consumer := newTestConsumer(func(ctx context.Context, body []byte) error {
+		key, _, _ := strings.Cut(string(body), "-")
+		if key == "A" {
+			time.Sleep(time.Millisecond) // Let the other keys get ahead
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		handled[key] = append(handled[key], string(body))
+		return nil
+	}, ConsumerOptions{
+		Workers: 4,
+		OrderingKey: func(body []byte) string {
+			key, _, _ := strings.Cut(string(body), "-")
+			return key
+		},
+	})
+
+	var bodies []string
+	for i := 0; i < 20; i++ {
+		for _, key := range []string{"A", "B", "C"} {
+			bodies = append(bodies, fmt.Sprintf("%s-%02d", key, i))
+		}
+	}
+	consumer.process(context.Background(), deliveries(&fakeAcknowledger{}, bodies...))
+
+	for _, key := range []string{"A", "B", "C"} {
+		got := handled[key]
+		if len(got) != 20 {
+			t.Fatalf("handled %d messages of %s, want 20", len(got), key)
+		}
+		for i, body := range got {
+			if want := fmt.Sprintf("%s-%02d", key, i); body != want {
+				t.Fatalf("messages of %s handled as %v, want in delivery order", key, got)
+			}
+		}
+	}
+}
+
+func TestConsumerRequeuesUnstartedDeliveriesOnStop(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var handlerErr error
+	consumer := newTestConsumer(func(ctx context.Context, body []byte) error {
+		started <- struct{}{}
+		<-release
+		handlerErr = ctx.Err()
+		return nil
+	}, ConsumerOptions{
+		Workers:     1,
+		Prefetch:    3,
+		OrderingKey: func(body []byte) string { return "COMP-1" },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ack := &fakeAcknowledger{}
+	done := make(chan struct{})
+	go func() {
+		consumer.process(ctx, deliveries(ack, "1", "2", "3"))
+		close(done)
+	}()
+
+	<-started
+	for consumer.Stats().InFlight != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	close(release)
+	<-done
+
+	if handlerErr != nil {
+		t.Errorf("handler context error = %v, want the message in progress to finish", handlerErr)
+	}
+	if len(ack.acked) != 1 || ack.acked[0] != 1 {
+		t.Errorf("acked %v, want only the message in progress", ack.acked)
+	}
+	if len(ack.requeued) != 2 {
+		t.Errorf("requeued %v, want the 2 messages not started", ack.requeued)
+	}
+	if stats := consumer.Stats(); stats.InFlight != 0 || stats.Handled != 1 {
+		t.Errorf("stats = %+v, want 1 handled and nothing in flight", stats)
+	}
+}
+
+func TestConsumerOptionsDefaults(t *testing.T) {
+	stats := newTestConsumer(nil, ConsumerOptions{}).Stats()
+	if stats.Workers != 1 || stats.Prefetch != 1 {
+		t.Errorf("stats = %+v, want 1 worker and a prefetch of 1", stats)
+	}
+
+	stats = newTestConsumer(nil, ConsumerOptions{Workers: 8}).Stats()
+	if stats.Prefetch != 8 {
+		t.Errorf("prefetch = %d, want the number of workers", stats.Prefetch)
+	}
+}
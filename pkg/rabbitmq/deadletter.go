@@ -0,0 +1,142 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DeadLetter is a message in a dead letter queue, with the failure the consumer recorded
+type DeadLetter struct {
+	MessageID          string
+	ContentType        string
+	Headers            amqp.Table
+	Body               []byte
+	Reason             string
+	FailedAt           string
+	RetryCount         int
+	OriginalExchange   string
+	OriginalRoutingKey string
+}
+
+func newDeadLetter(delivery amqp.Delivery) *DeadLetter {
+	header := func(key string) string {
+		value, _ := delivery.Headers[key].(string)
+		return value
+	}
+	return &DeadLetter{
+		MessageID:          delivery.MessageId,
+		ContentType:        delivery.ContentType,
+		Headers:            delivery.Headers,
+		Body:               delivery.Body,
+		Reason:             header(HeaderFailureReason),
+		FailedAt:           header(HeaderFailedAt),
+		RetryCount:         intHeader(delivery.Headers[HeaderRetryCount]),
+		OriginalExchange:   header(HeaderOriginalExchange),
+		OriginalRoutingKey: header(HeaderOriginalRoutingKey),
+	}
+}
+
+// ErrStopVisit ends a Visit without error once the callback has handled the current letter
+var ErrStopVisit = errors.New("stop visiting dead letters")
+
+// DeadLetters inspects and reprocesses the dead letter queue of a consumed queue
+type DeadLetters struct {
+	conn      *Connection
+	queue     string
+	publisher *Publisher
+}
+
+func NewDeadLetters(conn *Connection, queue string) *DeadLetters {
+	return &DeadLetters{conn: conn, queue: queue, publisher: NewPublisher(conn, "")}
+}
+
+// Visit calls fn with up to limit dead letters in queue order, all of them if limit is 0.
+// Messages for which fn returns true are removed from the queue; the others, and all the
+// remaining ones when fn fails, are put back in their place. fn returns ErrStopVisit to end early.
+//
+// Only the messages in the queue when the visit starts are visited: a replayed message that
+// fails again lands back in the queue meanwhile, and must not be replayed over and over.
+func (d *DeadLetters) Visit(limit int, fn func(*DeadLetter) (remove bool, err error)) error {
+	ch := d.conn.Channel()
+
+	queue, err := ch.QueueDeclarePassive(DeadLetterQueue(d.queue), true, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	if limit == 0 || limit > queue.Messages {
+		limit = queue.Messages
+	}
+
+	// Messages are held unacknowledged until the end so each get returns the next one
+	var kept []amqp.Delivery
+	defer func() {
+		for _, delivery := range kept {
+			delivery.Nack(false, true)
+		}
+	}()
+
+	for seen := 0; seen < limit; seen++ {
+		delivery, ok, err := ch.Get(DeadLetterQueue(d.queue), false)
+		if err != nil || !ok {
+			return err
+		}
+
+		remove, err := fn(newDeadLetter(delivery))
+		stop := errors.Is(err, ErrStopVisit)
+		if err != nil && !stop {
+			kept = append(kept, delivery)
+			return err
+		}
+
+		if !remove {
+			kept = append(kept, delivery)
+		} else if err := delivery.Ack(false); err != nil {
+			return err
+		}
+		if stop {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Replay publishes the dead letter back to where it was first published, with body in
+// place of its own if not nil. It keeps its message ID; failure headers are dropped so it
+// gets all its retries again.
+func (d *DeadLetters) Replay(ctx context.Context, letter *DeadLetter, body []byte) error {
+	if body == nil {
+		body = letter.Body
+	}
+
+	headers := amqp.Table{}
+	for key, value := range letter.Headers {
+		headers[key] = value
+	}
+	for _, key := range []string{HeaderRetryCount, HeaderFailureReason, HeaderFailedAt,
+		"x-death", "x-first-death-exchange", "x-first-death-queue", "x-first-death-reason",
+		"x-last-death-exchange", "x-last-death-queue", "x-last-death-reason"} {
+		delete(headers, key)
+	}
+
+	exchange, routingKey := letter.OriginalExchange, letter.OriginalRoutingKey
+	if routingKey == "" {
+		// Dead-lettered by the broker rather than the consumer: deliver to the queue directly
+		exchange, routingKey = "", d.queue
+	}
+
+	return d.publisher.PublishMessage(ctx, exchange, routingKey, amqp.Publishing{
+		Headers:     headers,
+		MessageId:   letter.MessageID,
+		ContentType: letter.ContentType,
+		Body:        body,
+		Timestamp:   time.Now(),
+	})
+}
+
+// Purge removes every message from the dead letter queue, returning how many there were
+func (d *DeadLetters) Purge() (int, error) {
+	return d.conn.Channel().QueuePurge(DeadLetterQueue(d.queue), false)
+}
@@ -0,0 +1,35 @@
+package rabbitmq
+
+import (
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestNewDeadLetterReadsFailureHeaders(t *testing.T) {
+	letter := newDeadLetter(amqp.Delivery{
+		MessageId: "3f2a9c0e",
+		Body:      []byte(`{"transaction_id":"TXN-1"}`),
+		Headers: amqp.Table{
+			HeaderRetryCount:         int32(5),
+			HeaderFailureReason:      "database unavailable",
+			HeaderFailedAt:           "2025-08-01T12:00:00Z",
+			HeaderOriginalExchange:   "transactions",
+			HeaderOriginalRoutingKey: "transaction.register",
+		},
+	})
+
+	if letter.RetryCount != 5 || letter.Reason != "database unavailable" || letter.FailedAt != "2025-08-01T12:00:00Z" {
+		t.Errorf("letter = %+v, want the recorded failure", letter)
+	}
+	if letter.OriginalExchange != "transactions" || letter.OriginalRoutingKey != "transaction.register" {
+		t.Errorf("letter = %+v, want the original exchange and routing key", letter)
+	}
+}
+
+func TestNewDeadLetterWithoutHeaders(t *testing.T) {
+	letter := newDeadLetter(amqp.Delivery{MessageId: "3f2a9c0e"})
+	if letter.RetryCount != 0 || letter.Reason != "" || letter.OriginalRoutingKey != "" {
+		t.Errorf("letter = %+v, want empty failure fields", letter)
+	}
+}
@@ -2,16 +2,55 @@ package rabbitmq
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// Outcomes of a publish the broker did not accept. A returned message fails with a
+// *ReturnedError, which matches ErrUnroutable.
+var (
+	ErrNacked        = errors.New("message nacked by the broker")
+	ErrUnroutable    = errors.New("message could not be routed to any queue")
+	ErrChannelClosed = errors.New("channel closed before the broker confirmed the message")
+)
+
+// headerPublishID identifies each publish so a return can be matched to it. Returns carry no
+// delivery tag, and the message ID belongs to the caller.
+const headerPublishID = "x-publish-id"
+
+// ReturnedError is a mandatory message the broker returned because no queue is bound for it
+type ReturnedError struct {
+	Exchange   string
+	RoutingKey string
+	ReplyCode  uint16
+	ReplyText  string
+}
+
+func (e *ReturnedError) Error() string {
+	return fmt.Sprintf("message returned by the broker: %d %s (exchange %q, routing key %q)",
+		e.ReplyCode, e.ReplyText, e.Exchange, e.RoutingKey)
+}
+
+func (e *ReturnedError) Is(target error) bool {
+	return target == ErrUnroutable
+}
+
+// Publisher publishes mandatory, persistent messages on a channel in confirm mode, so a
+// publish only succeeds once the broker has routed the message and taken responsibility for it
 type Publisher struct {
 	conn     *Connection
 	exchange string
+
+	// mu serializes publishes, keeping delivery tags in step with the channel
+	mu       sync.Mutex
+	confirms *confirmer
 }
 
 func NewPublisher(conn *Connection, exchange string) *Publisher {
@@ -21,39 +60,264 @@ func NewPublisher(conn *Connection, exchange string) *Publisher {
 	}
 }
 
+// State is the state of the publisher's connection
+func (p *Publisher) State() State {
+	return p.conn.State()
+}
+
+// PublishJSON publishes the message as JSON and waits for the broker to confirm it
 func (p *Publisher) PublishJSON(ctx context.Context, routingKey string, message interface{}) error {
 	body, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	return p.conn.ch.PublishWithContext(
-		ctx,
-		p.exchange,
-		routingKey,
-		false, // mandatory
-		false, // immediate
-		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			Timestamp:    time.Now(),
-			DeliveryMode: amqp.Persistent, // Make message persistent
-		},
-	)
+	return p.Publish(ctx, routingKey, body, "application/json")
 }
 
+// Publish publishes the body and waits for the broker to confirm it
 func (p *Publisher) Publish(ctx context.Context, routingKey string, body []byte, contentType string) error {
-	return p.conn.ch.PublishWithContext(
+	return p.PublishMessage(ctx, p.exchange, routingKey, amqp.Publishing{ContentType: contentType, Body: body})
+}
+
+// PublishMessage publishes msg to any exchange and waits for the broker to confirm it, for
+// callers that need to set headers or a per-message TTL
+func (p *Publisher) PublishMessage(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	pending, err := p.send(ctx, exchange, routingKey, msg)
+	if err != nil {
+		return err
+	}
+	return pending.wait(ctx)
+}
+
+// PublishJSONBatch publishes the messages and waits until the broker confirms each of
+// them. It returns one error per message, nil for those the broker acknowledged.
+func (p *Publisher) PublishJSONBatch(ctx context.Context, routingKey string, messages []interface{}) []error {
+	errs := make([]error, len(messages))
+
+	// Publish everything first so the broker confirms the batch instead of each round trip
+	pendings := make([]*pendingPublish, len(messages))
+	for i, message := range messages {
+		body, err := json.Marshal(message)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to marshal message: %w", err)
+			continue
+		}
+		pendings[i], errs[i] = p.send(ctx, p.exchange, routingKey, amqp.Publishing{ContentType: "application/json", Body: body})
+	}
+
+	for i, pending := range pendings {
+		if pending != nil {
+			errs[i] = pending.wait(ctx)
+		}
+	}
+	return errs
+}
+
+// send publishes a message without waiting for its confirmation. The message is made
+// persistent and given a new ID unless the caller set one.
+func (p *Publisher) send(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) (*pendingPublish, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	confirms, err := p.confirmer()
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := confirms.add(confirms.ch.GetNextPublishSeqNo())
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the headers so the caller's table is not modified
+	headers := amqp.Table{}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	headers[headerPublishID] = pending.publishID
+	msg.Headers = headers
+	if msg.MessageId == "" {
+		msg.MessageId = pending.publishID
+	}
+	msg.DeliveryMode = amqp.Persistent // Make message persistent
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+
+	err = confirms.ch.PublishWithContext(
 		ctx,
-		p.exchange,
+		exchange,
 		routingKey,
-		false, // mandatory
+		true,  // mandatory: unroutable messages are returned instead of dropped
 		false, // immediate
-		amqp.Publishing{
-			ContentType:  contentType,
-			Body:         body,
-			Timestamp:    time.Now(),
-			DeliveryMode: amqp.Persistent, // Make message persistent
-		},
+		msg,
 	)
-}
\ No newline at end of file
+	if err != nil {
+		confirms.remove(pending)
+		return nil, err
+	}
+	return pending, nil
+}
+
+// confirmer returns the confirmation tracking of the connection's channel, putting the
+// channel in confirm mode on first use. Callers hold p.mu.
+func (p *Publisher) confirmer() (*confirmer, error) {
+	ch := p.conn.Channel()
+	if p.confirms != nil && p.confirms.ch == ch {
+		return p.confirms, nil
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	p.confirms = newConfirmer(ch)
+	return p.confirms, nil
+}
+
+// pendingPublish is a message awaiting its confirmation; err is set before done is closed
+type pendingPublish struct {
+	tag       uint64
+	publishID string
+	done      chan struct{}
+	err       error
+}
+
+func (p *pendingPublish) wait(ctx context.Context) error {
+	select {
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for publisher confirm: %w", ctx.Err())
+	}
+}
+
+// confirmer matches the returns and confirmations of one channel to the pending publishes.
+// Returns carry no delivery tag, so messages are matched to them by their publish ID header.
+type confirmer struct {
+	ch *amqp.Channel
+
+	mu          sync.Mutex
+	pending     map[uint64]*pendingPublish
+	byPublishID map[string]*pendingPublish
+	closed      bool
+}
+
+func newConfirmer(ch *amqp.Channel) *confirmer {
+	c := &confirmer{
+		ch:          ch,
+		pending:     map[uint64]*pendingPublish{},
+		byPublishID: map[string]*pendingPublish{},
+	}
+
+	// Both listeners are unbuffered and read by one goroutine: the broker sends a message's
+	// return before its confirmation, and the client then hands them over in that order
+	returns := ch.NotifyReturn(make(chan amqp.Return))
+	confirmations := ch.NotifyPublish(make(chan amqp.Confirmation))
+	go c.run(returns, confirmations)
+
+	return c
+}
+
+func (c *confirmer) run(returns <-chan amqp.Return, confirmations <-chan amqp.Confirmation) {
+	for {
+		select {
+		case ret, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			c.returned(ret)
+		case confirmation, ok := <-confirmations:
+			if !ok {
+				c.close()
+				return
+			}
+			c.confirm(confirmation)
+		}
+	}
+}
+
+func (c *confirmer) add(tag uint64) (*pendingPublish, error) {
+	publishID, err := newMessageID()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrChannelClosed
+	}
+	pending := &pendingPublish{tag: tag, publishID: publishID, done: make(chan struct{})}
+	c.pending[tag] = pending
+	c.byPublishID[publishID] = pending
+	return pending, nil
+}
+
+// remove forgets a message that could not be published
+func (c *confirmer) remove(pending *pendingPublish) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.pending, pending.tag)
+	delete(c.byPublishID, pending.publishID)
+}
+
+func (c *confirmer) returned(ret amqp.Return) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	publishID, _ := ret.Headers[headerPublishID].(string)
+	if pending, ok := c.byPublishID[publishID]; ok {
+		pending.err = &ReturnedError{
+			Exchange:   ret.Exchange,
+			RoutingKey: ret.RoutingKey,
+			ReplyCode:  ret.ReplyCode,
+			ReplyText:  ret.ReplyText,
+		}
+	}
+}
+
+func (c *confirmer) confirm(confirmation amqp.Confirmation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.pending[confirmation.DeliveryTag]
+	if !ok {
+		return
+	}
+	delete(c.pending, pending.tag)
+	delete(c.byPublishID, pending.publishID)
+
+	// A returned message is still acked, as the broker is done with it
+	if pending.err == nil && !confirmation.Ack {
+		pending.err = ErrNacked
+	}
+	close(pending.done)
+}
+
+// close fails the messages still awaiting confirmation when the channel closes
+func (c *confirmer) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for tag, pending := range c.pending {
+		if pending.err == nil {
+			pending.err = ErrChannelClosed
+		}
+		close(pending.done)
+		delete(c.pending, tag)
+	}
+	c.byPublishID = map[string]*pendingPublish{}
+}
+
+func newMessageID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate message id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,58 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func newTestConfirmer() *confirmer {
+	return &confirmer{
+		pending:     map[uint64]*pendingPublish{},
+		byPublishID: map[string]*pendingPublish{},
+	}
+}
+
+func TestConfirmerOutcomes(t *testing.T) {
+	c := newTestConfirmer()
+	acked, _ := c.add(1)
+	nacked, _ := c.add(2)
+	returned, _ := c.add(3)
+	unconfirmed, _ := c.add(4)
+
+	c.returned(amqp.Return{Headers: amqp.Table{headerPublishID: returned.publishID}, ReplyCode: 312, ReplyText: "NO_ROUTE", RoutingKey: "transaction.register"})
+	c.confirm(amqp.Confirmation{DeliveryTag: 1, Ack: true})
+	c.confirm(amqp.Confirmation{DeliveryTag: 2, Ack: false})
+	c.confirm(amqp.Confirmation{DeliveryTag: 3, Ack: true})
+
+	ctx := context.Background()
+	if err := acked.wait(ctx); err != nil {
+		t.Errorf("acked message error = %v", err)
+	}
+	if err := nacked.wait(ctx); !errors.Is(err, ErrNacked) {
+		t.Errorf("nacked message error = %v, want ErrNacked", err)
+	}
+
+	err := returned.wait(ctx)
+	var returnedErr *ReturnedError
+	if !errors.Is(err, ErrUnroutable) || !errors.As(err, &returnedErr) || returnedErr.ReplyCode != 312 {
+		t.Errorf("returned message error = %v, want a ReturnedError matching ErrUnroutable", err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	if err := unconfirmed.wait(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unconfirmed message error = %v, want the context error", err)
+	}
+
+	c.close()
+	if err := unconfirmed.wait(ctx); !errors.Is(err, ErrChannelClosed) {
+		t.Errorf("message pending on close error = %v, want ErrChannelClosed", err)
+	}
+	if _, err := c.add(5); !errors.Is(err, ErrChannelClosed) {
+		t.Errorf("add after close error = %v, want ErrChannelClosed", err)
+	}
+}
@@ -0,0 +1,145 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Headers the consumer adds to the messages it retries or dead-letters
+const (
+	HeaderRetryCount         = "x-retry-count"
+	HeaderFailureReason      = "x-failure-reason"
+	HeaderFailedAt           = "x-failed-at"
+	HeaderOriginalExchange   = "x-original-exchange"
+	HeaderOriginalRoutingKey = "x-original-routing-key"
+)
+
+// RetryPolicy bounds the retries of a failed message. Retry n waits BaseDelay doubled
+// n-1 times, capped at MaxDelay; zero fields take the defaults of DefaultRetryPolicy.
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryPolicy retries 5 times over about 31 seconds
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 5,
+	BaseDelay:  time.Second,
+	MaxDelay:   5 * time.Minute,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxRetries == 0 {
+		p.MaxRetries = DefaultRetryPolicy.MaxRetries
+	}
+	if p.BaseDelay == 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	return p
+}
+
+func (p RetryPolicy) delay(retry int) time.Duration {
+	return exponential(p.BaseDelay, p.MaxDelay, retry)
+}
+
+// RetryQueue is the queue holding messages of queue waiting for their n-th retry. Each retry
+// has its own queue so all its messages share one TTL and expire in order.
+func RetryQueue(queue string, retry int) string {
+	return fmt.Sprintf("%s.retry.%d", queue, retry)
+}
+
+// DeadLetterExchange is the exchange failed messages of queue are published to
+func DeadLetterExchange(queue string) string {
+	return queue + ".dlx"
+}
+
+// DeadLetterQueue is the queue bound to the dead letter exchange of queue
+func DeadLetterQueue(queue string) string {
+	return queue + ".dlq"
+}
+
+// declareRetryTopology declares the dead letter exchange and queue and one retry queue per
+// retry. Retry queues dead-letter expired messages back to queue through the default exchange.
+// The consumed queue itself is left as declared, so its arguments do not change.
+func declareRetryTopology(conn *Connection, queue string, policy RetryPolicy) error {
+	if err := conn.DeclareExchange(DeadLetterExchange(queue), "fanout", true, false, false, false, nil); err != nil {
+		return err
+	}
+	if _, err := conn.DeclareQueue(DeadLetterQueue(queue), true, false, false, false, nil); err != nil {
+		return err
+	}
+	if err := conn.BindQueue(DeadLetterQueue(queue), "", DeadLetterExchange(queue), false, nil); err != nil {
+		return err
+	}
+
+	for retry := 1; retry <= policy.MaxRetries; retry++ {
+		_, err := conn.DeclareQueue(RetryQueue(queue, retry), true, false, false, false, amqp.Table{
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queue,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failureHeaders copies the headers of a delivery, recording where it was first published
+// since retried messages come back through the default exchange
+func failureHeaders(delivery amqp.Delivery) amqp.Table {
+	headers := amqp.Table{}
+	for key, value := range delivery.Headers {
+		headers[key] = value
+	}
+	if _, ok := headers[HeaderOriginalRoutingKey]; !ok {
+		headers[HeaderOriginalExchange] = delivery.Exchange
+		headers[HeaderOriginalRoutingKey] = delivery.RoutingKey
+	}
+	return headers
+}
+
+// retryCount is how many times a message of queue was retried: the count the consumer
+// recorded, or the broker's x-death count of expirations from the retry queues
+func retryCount(headers amqp.Table, queue string) int {
+	count := intHeader(headers[HeaderRetryCount])
+
+	deaths, _ := headers["x-death"].([]interface{})
+	expired := 0
+	for _, d := range deaths {
+		death, ok := d.(amqp.Table)
+		if !ok {
+			continue
+		}
+		from, _ := death["queue"].(string)
+		if death["reason"] == "expired" && strings.HasPrefix(from, queue+".retry.") {
+			expired += intHeader(death["count"])
+		}
+	}
+
+	if expired > count {
+		return expired
+	}
+	return count
+}
+
+func intHeader(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,60 @@
+package rabbitmq
+
+import (
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 8, BaseDelay: 2 * time.Second}.withDefaults()
+
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second}
+	for i, delay := range want {
+		if got := policy.delay(i + 1); got != delay {
+			t.Errorf("delay(%d) = %v, want %v", i+1, got, delay)
+		}
+	}
+	if got := policy.delay(20); got != 5*time.Minute {
+		t.Errorf("delay(20) = %v, want it capped at the default 5m", got)
+	}
+}
+
+func TestRetryCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers amqp.Table
+		want    int
+	}{
+		{"first delivery", nil, 0},
+		{"consumer header", amqp.Table{HeaderRetryCount: int32(2)}, 2},
+		{"x-death only", amqp.Table{"x-death": []interface{}{
+			amqp.Table{"queue": "transaction.register.retry.1", "reason": "expired", "count": int64(1)},
+			amqp.Table{"queue": "transaction.register.retry.2", "reason": "expired", "count": int64(1)},
+			amqp.Table{"queue": "transaction.register", "reason": "rejected", "count": int64(4)},
+		}}, 2},
+		{"x-death of another queue", amqp.Table{"x-death": []interface{}{
+			amqp.Table{"queue": "other.retry.1", "reason": "expired", "count": int64(3)},
+		}}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := retryCount(tt.headers, "transaction.register"); got != tt.want {
+			t.Errorf("%s: retryCount = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFailureHeadersKeepOrigin(t *testing.T) {
+	first := failureHeaders(amqp.Delivery{Exchange: "transactions", RoutingKey: "transaction.register"})
+	if first[HeaderOriginalExchange] != "transactions" || first[HeaderOriginalRoutingKey] != "transaction.register" {
+		t.Fatalf("headers = %v, want the original exchange and routing key", first)
+	}
+
+	// A retried message comes back through the default exchange
+	retried := failureHeaders(amqp.Delivery{Headers: first, Exchange: "", RoutingKey: "transaction.register"})
+	if retried[HeaderOriginalExchange] != "transactions" {
+		t.Errorf("headers = %v, want the first exchange kept", retried)
+	}
+}